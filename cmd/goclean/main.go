@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/paperalt/goclean/internal/cleaner"
@@ -18,9 +19,28 @@ func main() {
 	noConfirm := flag.Bool("yes", false, "Skip confirmation prompt")
 	useTUI := flag.Bool("tui", true, "Use Text User Interface (default true)")
 	noTUI := flag.Bool("no-tui", false, "Disable TUI and use CLI mode (overrides -tui)")
+	orphanThumbs := flag.Bool("orphan-thumbnails", false, "Only remove thumbnails whose source file is gone or changed")
+	dedup := flag.String("dedup", "delete", "What to do with duplicate copies: delete, hardlink or reflink")
+	dedupOldest := flag.Bool("dedup-keep-oldest", false, "In CLI mode, keep the oldest copy of each duplicate group and dedup the rest")
+	nixKeep := flag.Int("nix-keep", cleaner.DefaultNixKeepLast, "Number of newest Nix generations to keep per profile")
+	nixKeepDays := flag.Int("nix-keep-days", 0, "Also keep Nix generations newer than this many days")
+	downloads := flag.String("downloads", "", "Comma separated download categories to clean as a whole (package, appimage, disk image, installer, archive)")
 
 	flag.Parse()
 
+	var downloadCategories []string
+	for _, cat := range strings.Split(*downloads, ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			downloadCategories = append(downloadCategories, cat)
+		}
+	}
+
+	dedupMode, ok := cleaner.ParseDedupMode(*dedup)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid -dedup value %q (want delete, hardlink or reflink)\n", *dedup)
+		os.Exit(2)
+	}
+
 	// Logic to determine if we use TUI
 	// If no-tui is true, disable checks.
 	isManualCLI := *dryRun || *noConfirm || !*useTUI || *noTUI
@@ -29,15 +49,33 @@ func main() {
 		&cleaner.AptCleaner{},
 		&cleaner.LogCleaner{},
 		&cleaner.TrashCleaner{},
-		&cleaner.UserCacheCleaner{},
+		&cleaner.UserCacheCleaner{OrphansOnly: *orphanThumbs},
 		&cleaner.DockerCleaner{},
 		&cleaner.BrowserCleaner{},
 		&cleaner.GoCacheCleaner{},
 		&cleaner.DynamicCacheCleaner{},
-		&cleaner.NpmCacheCleaner{},
+		&cleaner.JSCacheCleaner{},
 		&cleaner.FlatpakCleaner{},
 		&cleaner.TmpCleaner{},
 		&cleaner.CargoCacheCleaner{},
+		&cleaner.LanguageCacheCleaner{},
+		&cleaner.CompilerCacheCleaner{},
+		&cleaner.BazelCleaner{},
+		&cleaner.MLModelCleaner{},
+		&cleaner.BrowserAutomationCleaner{},
+		&cleaner.RemoteIDECleaner{},
+		&cleaner.JetBrainsCleaner{},
+		&cleaner.CrashCleaner{KeepNewest: true},
+		&cleaner.LeftoverCleaner{},
+		&cleaner.DuplicateCleaner{DedupMode: dedupMode, KeepOldest: *dedupOldest},
+		&cleaner.EmptyDirCleaner{},
+		&cleaner.DownloadsCleaner{Categories: downloadCategories},
+		&cleaner.AppImageCleaner{},
+		&cleaner.GitRepoCleaner{},
+		&cleaner.VMCleaner{},
+		&cleaner.LocalKubeCleaner{},
+		&cleaner.SteamCleaner{},
+		&cleaner.NixCleaner{KeepLast: *nixKeep, KeepDays: *nixKeepDays},
 		&cleaner.AppCacheCleaner{},
 		&cleaner.LargeFileCleaner{SkipConfirmation: *noConfirm},
 	}
@@ -112,7 +150,7 @@ func runCLI(cleaners []cleaner.Cleaner, dryRun, noConfirm bool) {
 	ui.Info("\nCleaning...\n")
 	for _, c := range cleanable {
 		fmt.Printf("Cleaning %s... ", c.Name())
-		if err := c.Clean(); err != nil {
+		if err := cleanWithProgress(c); err != nil {
 			ui.Error("FAILED: %v\n", err)
 		} else {
 			ui.Success("Done\n")
@@ -120,3 +158,33 @@ func runCLI(cleaners []cleaner.Cleaner, dryRun, noConfirm bool) {
 	}
 	ui.Success("\nCleanup complete!\n")
 }
+
+// cleanWithProgress runs c.Clean, printing the progress of cleaners that
+// report it on the current line until Clean returns
+func cleanWithProgress(c cleaner.Cleaner) error {
+	pc, ok := c.(cleaner.ProgressCleaner)
+	if !ok {
+		return c.Clean()
+	}
+
+	done := make(chan error)
+	go func() { done <- pc.Clean() }()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	prefix := fmt.Sprintf("Cleaning %s... ", c.Name())
+	width := 0
+	for {
+		select {
+		case err := <-done:
+			fmt.Printf("\r%s%s\r%s", prefix, strings.Repeat(" ", width), prefix)
+			return err
+		case <-ticker.C:
+			status := pc.Progress()
+			if len(status) > width {
+				width = len(status)
+			}
+			fmt.Printf("\r%s%-*s", prefix, width, status)
+		}
+	}
+}
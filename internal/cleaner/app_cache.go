@@ -3,9 +3,57 @@ package cleaner
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-type AppCacheCleaner struct{}
+// chromiumCacheDirs are the cache subdirectories Electron and other
+// Chromium based apps create inside their config dir
+var chromiumCacheDirs = []string{
+	"Cache",
+	"Code Cache",
+	"GPUCache",
+	"DawnCache",
+	"DawnGraphiteCache",
+	"DawnWebGPUCache",
+	filepath.Join("Service Worker", "CacheStorage"),
+}
+
+// appCacheExtras are app specific caches that don't follow the Chromium layout
+var appCacheExtras = map[string][]string{
+	"Code":    {"CachedData", "CachedExtensionVSIXs"},
+	"spotify": {"Storage"},
+}
+
+// appCacheSkip lists config dirs handled by BrowserCleaner
+var appCacheSkip = browserConfigDirs()
+
+// browserConfigDirs returns the dirs directly below ~/.config (or a Flatpak's
+// config dir) that hold the Chromium based browsers of browserDefs
+func browserConfigDirs() map[string]bool {
+	dirs := make(map[string]bool)
+	for _, b := range browserDefs {
+		if b.firefox {
+			continue
+		}
+		parts := strings.Split(b.config, "/")
+		for i, part := range parts[:len(parts)-1] {
+			if part == ".config" || part == "config" {
+				dirs[parts[i+1]] = true
+				break
+			}
+		}
+	}
+	return dirs
+}
+
+// AppCacheCleaner discovers Chromium style caches of Electron apps in
+// ~/.config/<app> and Flatpak ~/.var/app/<id>/config/<app>, one entry per app.
+type AppCacheCleaner struct {
+	detailSet
+
+	// cacheDirs maps each app dir to the cache subdirectories found in it
+	cacheDirs map[string][]string
+}
 
 func (c *AppCacheCleaner) Name() string {
 	return "App Specific Caches"
@@ -15,54 +63,121 @@ func (c *AppCacheCleaner) RequiresRoot() bool {
 	return false
 }
 
-func (c *AppCacheCleaner) getCachePaths() ([]string, error) {
+// appConfigRoots returns the dirs apps keep their config in, keyed by the
+// prefix used in labels (the Flatpak app ID, or "" for ~/.config)
+func appConfigRoots() (map[string]string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	configDir := filepath.Join(home, ".config")
-
-	// Target heavy electron apps that usually bury Cache in ~/.config/<app>
-	return []string{
-		filepath.Join(configDir, "discord", "Cache"),
-		filepath.Join(configDir, "discord", "Code Cache"),
-		filepath.Join(configDir, "discord", "DawnCache"),
-
-		filepath.Join(configDir, "Slack", "Cache"),
-		filepath.Join(configDir, "Slack", "Code Cache"),
-		filepath.Join(configDir, "Slack", "Service Worker", "CacheStorage"),
-
-		filepath.Join(configDir, "Code", "Cache"),
-		filepath.Join(configDir, "Code", "CachedData"),
-		filepath.Join(configDir, "Code", "CachedExtensionVSIXs"),
-
-		filepath.Join(configDir, "spotify", "Storage"),
-	}, nil
+	roots := map[string]string{"": filepath.Join(home, ".config")}
+	flatpaks, _ := filepath.Glob(filepath.Join(home, ".var", "app", "*", "config"))
+	for _, dir := range flatpaks {
+		roots[filepath.Base(filepath.Dir(dir))] = dir
+	}
+	return roots, nil
 }
 
 func (c *AppCacheCleaner) Scan() (int64, error) {
-	paths, err := c.getCachePaths()
+	roots, err := appConfigRoots()
 	if err != nil {
 		return 0, err
 	}
 
+	c.found = nil
+	c.cacheDirs = make(map[string][]string)
+	procs := SnapshotProcesses()
+
+	// "" sorts first, so ~/.config apps are listed before Flatpak ones
+	for _, prefix := range sortedKeys(roots) {
+		entries, err := os.ReadDir(roots[prefix])
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() || appCacheSkip[e.Name()] {
+				continue
+			}
+			appDir := filepath.Join(roots[prefix], e.Name())
+			label := e.Name()
+			if prefix != "" {
+				label = prefix + "/" + label
+			}
+
+			if c.addApp(appDir, label, procs) {
+				continue
+			}
+
+			// Some vendors nest apps one level deeper, e.g. Microsoft/Microsoft Teams
+			children, _ := os.ReadDir(appDir)
+			for _, child := range children {
+				if child.IsDir() {
+					c.addApp(filepath.Join(appDir, child.Name()), label+"/"+child.Name(), procs)
+				}
+			}
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+// addApp records the cache subdirectories of appDir, if it has any
+func (c *AppCacheCleaner) addApp(appDir, label string, procs *ProcessSnapshot) bool {
+	candidates := append([]string{}, chromiumCacheDirs...)
+	candidates = append(candidates, appCacheExtras[filepath.Base(appDir)]...)
+
+	var dirs, names []string
 	var size int64
-	for _, p := range paths {
+	inUse := false
+	for _, sub := range candidates {
+		p := filepath.Join(appDir, sub)
+		if !dirExists(p) {
+			continue
+		}
 		s, _ := simpleDirScan(p)
+		dirs = append(dirs, p)
+		names = append(names, sub)
 		size += s
+		inUse = inUse || procs.InUse(p)
+	}
+	if len(dirs) == 0 {
+		return false
+	}
+	if size == 0 {
+		return true
 	}
-	return size, nil
-}
 
-func (c *AppCacheCleaner) Clean() error {
-	paths, err := c.getCachePaths()
-	if err != nil {
-		return err
+	detail := FileDetail{
+		Path:     appDir,
+		Size:     size,
+		Label:    label + " (" + strings.Join(names, ", ") + ")",
+		Selected: true,
+	}
+	// Electron apps keep their cache files open; the binary is usually
+	// named after the config dir ("Slack" -> slack)
+	base := filepath.Base(appDir)
+	if inUse {
+		detail.Warning = "in use"
+		detail.Selected = false
+	} else if procs.Running(base, strings.ToLower(base)) {
+		detail.Warning = "owner app running"
+		detail.Selected = false
 	}
 
-	for _, p := range paths {
-		_ = os.RemoveAll(p)
+	c.cacheDirs[appDir] = dirs
+	c.found = append(c.found, detail)
+	return true
+}
+
+func (c *AppCacheCleaner) Clean() error {
+	idle := c.idleTargets(func(procs *ProcessSnapshot, t FileDetail) bool {
+		base := filepath.Base(t.Path)
+		return procs.Busy([]string{base, strings.ToLower(base)}, c.cacheDirs[t.Path])
+	})
+	for _, t := range idle {
+		for _, p := range c.cacheDirs[t.Path] {
+			_ = os.RemoveAll(p)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,56 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppCacheCleanRechecks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeTestFiles(t, home, map[string]string{
+		".config/FooApp/Cache/data_0":   "cache",
+		".config/FooApp/GPUCache/index": "gpu",
+	})
+	c := &AppCacheCleaner{}
+	if _, err := c.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The app starts between Scan and Clean and opens its cache
+	f, err := os.Open(filepath.Join(home, ".config/FooApp/Cache/data_0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	// An open cache means the app runs: none of its caches are touched
+	for _, dir := range []string{"Cache", "GPUCache"} {
+		if !dirExists(filepath.Join(home, ".config/FooApp", dir)) {
+			t.Errorf("Clean removed %s of an app in use", dir)
+		}
+	}
+
+	f.Close()
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if dirExists(filepath.Join(home, ".config/FooApp/Cache")) {
+		t.Error("Clean kept the cache of an app no longer in use")
+	}
+}
+
+func TestAppCacheSkipsBrowsers(t *testing.T) {
+	for _, name := range []string{"google-chrome", "google-chrome-unstable", "microsoft-edge-beta", "BraveSoftware", "opera"} {
+		if !appCacheSkip[name] {
+			t.Errorf("%s is not skipped by AppCacheCleaner", name)
+		}
+	}
+	for _, name := range []string{"Slack", "chromium-extra", "mozilla"} {
+		if appCacheSkip[name] {
+			t.Errorf("%s is skipped by AppCacheCleaner", name)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package cleaner
+
+import (
+	"crypto/md5"
+	"debug/elf"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// appImageNameRe splits "Obsidian-1.4.16.AppImage" or
+// "app_v2.0.1-x86_64.AppImage" into name and version
+var appImageNameRe = regexp.MustCompile(`(?i)^(.+?)[-_. ]v?(\d+(?:\.\d+)+[0-9A-Za-z.+~]*)(?:[-_].*)?\.appimage$`)
+
+// appImage is one AppImage file found during Scan
+type appImage struct {
+	path    string
+	name    string
+	version string
+	// key identifies the app across versions
+	key     string
+	size    int64
+	modTime int64
+	// integration holds the desktop files and icons appimaged or
+	// AppImageLauncher created for this file
+	integration []string
+}
+
+// AppImageCleaner groups the AppImages in ~/Applications, ~/AppImages and
+// ~/.local/bin by app, using the embedded update information and the desktop
+// integration metadata where present and the file name otherwise, and offers
+// every version but the newest together with its desktop integration files.
+type AppImageCleaner struct {
+	detailSet
+
+	integration map[string][]string
+}
+
+func (c *AppImageCleaner) Name() string {
+	return "Old AppImage Versions"
+}
+
+func (c *AppImageCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *AppImageCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	var images []appImage
+	for _, dir := range []string{
+		filepath.Join(home, "Applications"),
+		filepath.Join(home, ".local", "bin"),
+		filepath.Join(home, "AppImages"),
+	} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() || !strings.HasSuffix(strings.ToLower(e.Name()), ".appimage") {
+				continue
+			}
+			if img, ok := readAppImage(home, filepath.Join(dir, e.Name())); ok {
+				images = append(images, img)
+			}
+		}
+	}
+
+	groups := make(map[string][]appImage)
+	for _, img := range images {
+		groups[img.key] = append(groups[img.key], img)
+	}
+
+	procs := SnapshotProcesses()
+	c.found = nil
+	c.integration = make(map[string][]string)
+	for _, key := range sortedKeys(groups) {
+		versions := groups[key]
+		if len(versions) < 2 {
+			continue
+		}
+		// Newest first; files without a version fall back to their mtime
+		sort.Slice(versions, func(i, j int) bool {
+			if v := compareVersions(versions[i].version, versions[j].version); v != 0 {
+				return v > 0
+			}
+			return versions[i].modTime > versions[j].modTime
+		})
+
+		for _, img := range versions[1:] {
+			size := img.size
+			for _, f := range img.integration {
+				if info, err := os.Stat(f); err == nil {
+					size += info.Size()
+				}
+			}
+			label := img.name
+			if img.version != "" {
+				label += " " + img.version
+			}
+			label += " (" + filepath.Base(img.path) + ", newest is " + filepath.Base(versions[0].path) + ")"
+
+			detail := FileDetail{Path: img.path, Size: size, Label: label, Selected: true}
+			// A running AppImage keeps its file open
+			if procs.InUse(img.path) || procs.Mentions(img.path) {
+				detail.Warning = "in use"
+				detail.Selected = false
+			}
+			c.integration[img.path] = img.integration
+			c.found = append(c.found, detail)
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+// readAppImage collects what identifies an AppImage: the update information
+// embedded in the .upd_info ELF section ("gh-releases-zsync|owner|repo|..."),
+// the X-AppImage-Name/Version of its desktop integration and its file name
+func readAppImage(home, path string) (appImage, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return appImage{}, false
+	}
+	img := appImage{path: path, size: info.Size(), modTime: info.ModTime().Unix()}
+
+	if m := appImageNameRe.FindStringSubmatch(filepath.Base(path)); m != nil {
+		img.name, img.version = m[1], m[2]
+	} else {
+		img.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	img.integration = appImageIntegration(home, path)
+	for _, f := range img.integration {
+		if !strings.HasSuffix(f, ".desktop") {
+			continue
+		}
+		keys := desktopEntryKeys(f, "X-AppImage-Name", "X-AppImage-Version")
+		if v := keys["X-AppImage-Version"]; v != "" {
+			img.version = v
+		}
+		if n := keys["X-AppImage-Name"]; n != "" {
+			img.name = n
+		}
+	}
+
+	img.key = "name:" + strings.ToLower(img.name)
+	if upd := appImageUpdateInfo(path); upd != "" {
+		img.key = "update:" + upd
+	}
+	return img, true
+}
+
+// appImageUpdateInfo returns the update information of a type 2 AppImage
+// without a trailing file name pattern, which may contain the version
+func appImageUpdateInfo(path string) string {
+	f, err := elf.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sec := f.Section(".upd_info")
+	if sec == nil {
+		return ""
+	}
+	data, err := sec.Data()
+	if err != nil {
+		return ""
+	}
+	info := strings.TrimRight(string(data), "\x00")
+	// "zsync|<url>" names a fixed URL; the longer forms end in a file pattern
+	if strings.Count(info, "|") >= 2 {
+		info = info[:strings.LastIndex(info, "|")]
+	}
+	return info
+}
+
+// appImageIntegration returns the desktop file and icons desktop integration
+// tools created for path. They are named after the md5 of the file URI:
+// appimagekit_<md5>-<name>.desktop and appimagekit_<md5>_<name>.png.
+func appImageIntegration(home, path string) []string {
+	uri := url.URL{Scheme: "file", Path: path}
+	sum := md5.Sum([]byte(uri.String()))
+	prefix := "appimagekit_" + hex.EncodeToString(sum[:])
+
+	files, _ := filepath.Glob(filepath.Join(home, ".local", "share", "applications", prefix+"*"))
+	icons, _ := filepath.Glob(filepath.Join(home, ".local", "share", "icons", "hicolor", "*", "apps", prefix+"*"))
+	return append(files, icons...)
+}
+
+func (c *AppImageCleaner) Clean() error {
+	for _, t := range c.targets() {
+		if err := os.Remove(t.Path); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		for _, f := range c.integration[t.Path] {
+			_ = os.Remove(f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package cleaner
+
+import (
+	"testing"
+)
+
+func TestAppImageNameRe(t *testing.T) {
+	tests := []struct {
+		file, name, version string
+	}{
+		{"Obsidian-1.4.16.AppImage", "Obsidian", "1.4.16"},
+		{"app_v2.0.1-x86_64.AppImage", "app", "2.0.1"},
+		{"Kdenlive-24.02.1-x86_64.appimage", "Kdenlive", "24.02.1"},
+		{"MyTool.AppImage", "", ""},
+	}
+	for _, tt := range tests {
+		var name, version string
+		if m := appImageNameRe.FindStringSubmatch(tt.file); m != nil {
+			name, version = m[1], m[2]
+		}
+		if name != tt.name || version != tt.version {
+			t.Errorf("appImageNameRe(%q) = %q, %q; want %q, %q", tt.file, name, version, tt.name, tt.version)
+		}
+	}
+}
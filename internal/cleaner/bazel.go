@@ -0,0 +1,168 @@
+package cleaner
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultBazelIdleDays is how long an output base may go unused before it
+// is flagged when IdleDays is not set.
+const defaultBazelIdleDays = 60
+
+// BazelCleaner finds Bazel output bases below ~/.cache/bazel, maps each back
+// to its workspace and flags those whose workspace is gone or idle. The
+// shared repository cache is listed as well but not preselected.
+type BazelCleaner struct {
+	detailSet
+
+	// IdleDays is how long an output base may go unused before it is preselected
+	IdleDays int
+}
+
+func (c *BazelCleaner) Name() string {
+	return "Bazel Output Bases"
+}
+
+func (c *BazelCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *BazelCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	idleDays := c.IdleDays
+	if idleDays <= 0 {
+		idleDays = defaultBazelIdleDays
+	}
+	idle := time.Duration(idleDays) * 24 * time.Hour
+
+	c.found = nil
+	userRoots, _ := filepath.Glob(filepath.Join(home, ".cache", "bazel", "_bazel_*"))
+	for _, userRoot := range userRoots {
+		entries, err := os.ReadDir(userRoot)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			path := filepath.Join(userRoot, e.Name())
+
+			// Output bases are named after the md5 of their workspace path
+			if len(e.Name()) != 32 {
+				if e.Name() == "cache" {
+					c.addRepositoryCache(filepath.Join(path, "repos"))
+				}
+				continue
+			}
+
+			size, _ := simpleDirScan(path)
+			if size == 0 {
+				continue
+			}
+
+			workspace := bazelWorkspace(path)
+			lastUsed := bazelLastUsed(path)
+			detail := FileDetail{Path: path, Size: size}
+
+			switch {
+			case bazelServerRunning(path):
+				detail.Label = workspace + " (server running)"
+			case workspace == "":
+				detail.Label = path + " (unknown workspace)"
+			case !dirExists(workspace):
+				detail.Label = workspace + " (workspace gone)"
+				detail.Selected = true
+			case time.Since(lastUsed) > idle:
+				detail.Label = workspace + " (idle " + strconv.Itoa(int(time.Since(lastUsed).Hours()/24)) + " days)"
+				detail.Selected = true
+			default:
+				detail.Label = workspace + " (active)"
+			}
+			c.found = append(c.found, detail)
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+func (c *BazelCleaner) addRepositoryCache(path string) {
+	size, _ := simpleDirScan(path)
+	if size == 0 {
+		return
+	}
+	c.found = append(c.found, FileDetail{
+		Path:  path,
+		Size:  size,
+		Label: "Repository cache (shared downloads)",
+	})
+}
+
+// bazelWorkspace reads the workspace path an output base belongs to from its
+// README ("WORKSPACE: /path") or the execroot's DO_NOT_BUILD_HERE marker.
+func bazelWorkspace(outputBase string) string {
+	if f, err := os.Open(filepath.Join(outputBase, "README")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if ws, ok := strings.CutPrefix(scanner.Text(), "WORKSPACE: "); ok {
+				return strings.TrimSpace(ws)
+			}
+		}
+	}
+
+	markers, _ := filepath.Glob(filepath.Join(outputBase, "execroot", "*", "DO_NOT_BUILD_HERE"))
+	for _, m := range markers {
+		if data, err := os.ReadFile(m); err == nil {
+			if ws := strings.TrimSpace(string(data)); ws != "" {
+				return ws
+			}
+		}
+	}
+	return ""
+}
+
+// bazelLastUsed returns when Bazel last touched the output base
+func bazelLastUsed(outputBase string) time.Time {
+	var latest time.Time
+	for _, p := range []string{outputBase, filepath.Join(outputBase, "lock"), filepath.Join(outputBase, "server"), filepath.Join(outputBase, "execroot")} {
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// bazelServerRunning reports whether the Bazel server of an output base is alive
+func bazelServerRunning(outputBase string) bool {
+	data, err := os.ReadFile(filepath.Join(outputBase, "server", "server.pid.txt"))
+	if err != nil {
+		return false
+	}
+	pid := strings.TrimSpace(string(data))
+	if pid == "" {
+		return false
+	}
+	_, err = os.Stat(filepath.Join("/proc", pid))
+	return err == nil
+}
+
+func (c *BazelCleaner) Clean() error {
+	var errs []error
+	for _, t := range c.targets() {
+		// Output bases are full of read-only files and directories
+		if err := forceRemoveAll(t.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
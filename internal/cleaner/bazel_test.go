@@ -0,0 +1,97 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	bazelBaseA = ".cache/bazel/_bazel_user/0123456789abcdef0123456789abcdef"
+	bazelBaseB = ".cache/bazel/_bazel_user/fedcba9876543210fedcba9876543210"
+)
+
+// writeBazelMarker points an output base at a workspace below home
+func writeBazelMarker(t *testing.T, home, base, workspace string) {
+	t.Helper()
+	writeTestFiles(t, home, map[string]string{
+		base + "/execroot/_main/DO_NOT_BUILD_HERE": filepath.Join(home, workspace),
+	})
+}
+
+func TestBazelScan(t *testing.T) {
+	files := map[string]string{
+		bazelBaseA + "/external/rules/BUILD": "build",
+		bazelBaseB + "/external/rules/BUILD": "build",
+		"ws/WORKSPACE":                       "",
+		".cache/bazel/_bazel_user/cache/repos/v1/content_addressable/sha256/ab/file": "download",
+	}
+	runScanCases(t, func() DetailCleaner { return &BazelCleaner{} }, []scanCase{
+		{
+			name:  "workspace gone",
+			files: files,
+			setup: func(t *testing.T, home string) {
+				writeTestFiles(t, home, map[string]string{bazelBaseA + "/README": "WORKSPACE: " + filepath.Join(home, "gone") + "\n"})
+				writeBazelMarker(t, home, bazelBaseB, "ws")
+			},
+			want: map[string]bool{
+				bazelBaseA:                             true,
+				bazelBaseB:                             false,
+				".cache/bazel/_bazel_user/cache/repos": false,
+			},
+		},
+		{
+			name:  "idle and unknown workspaces",
+			files: files,
+			setup: func(t *testing.T, home string) {
+				writeBazelMarker(t, home, bazelBaseA, "ws")
+				old := time.Now().Add(-90 * 24 * time.Hour)
+				for _, p := range []string{bazelBaseA + "/execroot", bazelBaseA} {
+					if err := os.Chtimes(filepath.Join(home, p), old, old); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			want: map[string]bool{
+				bazelBaseA:                             true,
+				bazelBaseB:                             false,
+				".cache/bazel/_bazel_user/cache/repos": false,
+			},
+		},
+	})
+}
+
+func TestBazelCleanReadOnly(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeTestFiles(t, home, map[string]string{
+		bazelBaseA + "/README":                      "WORKSPACE: " + filepath.Join(home, "gone") + "\n",
+		bazelBaseA + "/external/rules/BUILD":        "build",
+		bazelBaseA + "/external/rules/lib/defs.bzl": "defs",
+		bazelBaseB + "/external/rules/BUILD":        "build",
+		"ws/WORKSPACE":                              "",
+	})
+	writeBazelMarker(t, home, bazelBaseB, "ws")
+
+	c := &BazelCleaner{}
+	if _, err := c.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	// Bazel leaves read-only files in directories that cannot even be listed
+	gone := filepath.Join(home, bazelBaseA)
+	for _, dir := range []string{"external/rules/lib", "external/rules", "external"} {
+		if err := os.Chmod(filepath.Join(gone, dir), 0o300); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(gone); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after Clean", gone)
+	}
+	if !dirExists(filepath.Join(home, bazelBaseB)) {
+		t.Errorf("%s was removed", bazelBaseB)
+	}
+}
@@ -1,11 +1,79 @@
 package cleaner
 
 import (
+	"bufio"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-type BrowserCleaner struct{}
+// browserDef describes where one browser (or one packaging of it) keeps its
+// profiles. Paths are relative to the home directory.
+type browserDef struct {
+	name    string
+	firefox bool
+	// config is the Chromium user data dir, or the dir holding Firefox's profiles.ini
+	config string
+	// cache mirrors the profile dirs below ~/.cache (or the sandbox equivalent)
+	cache string
+	// procs are executable names, or paths where channels share a name
+	procs []string
+}
+
+var browserDefs = []browserDef{
+	{"Chrome", false, ".config/google-chrome", ".cache/google-chrome", []string{"/opt/google/chrome/chrome"}},
+	{"Chrome Beta", false, ".config/google-chrome-beta", ".cache/google-chrome-beta", []string{"/opt/google/chrome-beta/chrome"}},
+	{"Chrome Dev", false, ".config/google-chrome-unstable", ".cache/google-chrome-unstable", []string{"/opt/google/chrome-unstable/chrome"}},
+	{"Chromium", false, ".config/chromium", ".cache/chromium", []string{"chromium", "chromium-browser"}},
+	{"Brave", false, ".config/BraveSoftware/Brave-Browser", ".cache/BraveSoftware/Brave-Browser", []string{"brave"}},
+	{"Edge", false, ".config/microsoft-edge", ".cache/microsoft-edge", []string{"/opt/microsoft/msedge/msedge"}},
+	{"Edge Beta", false, ".config/microsoft-edge-beta", ".cache/microsoft-edge-beta", []string{"/opt/microsoft/msedge-beta/msedge"}},
+	{"Vivaldi", false, ".config/vivaldi", ".cache/vivaldi", []string{"vivaldi-bin"}},
+	{"Opera", false, ".config/opera", ".cache/opera", []string{"opera"}},
+	{"Chrome (Flatpak)", false, ".var/app/com.google.Chrome/config/google-chrome", ".var/app/com.google.Chrome/cache/google-chrome", []string{"chrome"}},
+	{"Chromium (Flatpak)", false, ".var/app/org.chromium.Chromium/config/chromium", ".var/app/org.chromium.Chromium/cache/chromium", []string{"chromium"}},
+	{"Brave (Flatpak)", false, ".var/app/com.brave.Browser/config/BraveSoftware/Brave-Browser", ".var/app/com.brave.Browser/cache/BraveSoftware/Brave-Browser", []string{"brave"}},
+	{"Edge (Flatpak)", false, ".var/app/com.microsoft.Edge/config/microsoft-edge", ".var/app/com.microsoft.Edge/cache/microsoft-edge", []string{"msedge"}},
+	{"Chromium (Snap)", false, "snap/chromium/common/chromium", "snap/chromium/common/.cache/chromium", []string{"chromium", "chrome"}},
+
+	{"Firefox", true, ".mozilla/firefox", ".cache/mozilla/firefox", []string{"firefox", "firefox-bin"}},
+	{"LibreWolf", true, ".librewolf", ".cache/librewolf", []string{"librewolf"}},
+	{"Waterfox", true, ".waterfox", ".cache/waterfox", []string{"waterfox"}},
+	{"Firefox (Flatpak)", true, ".var/app/org.mozilla.firefox/.mozilla/firefox", ".var/app/org.mozilla.firefox/cache/mozilla/firefox", []string{"firefox", "firefox-bin"}},
+	{"LibreWolf (Flatpak)", true, ".var/app/io.gitlab.librewolf-community/.librewolf", ".var/app/io.gitlab.librewolf-community/cache/librewolf", []string{"librewolf"}},
+	{"Firefox (Snap)", true, "snap/firefox/common/.mozilla/firefox", "snap/firefox/common/.cache/mozilla/firefox", []string{"firefox", "firefox-bin"}},
+}
+
+// chromiumProfileCaches are the cache dirs inside a Chromium profile
+var chromiumProfileCaches = []string{
+	"Cache",
+	"Code Cache",
+	"GPUCache",
+	"DawnCache",
+	"DawnGraphiteCache",
+	"DawnWebGPUCache",
+	filepath.Join("Service Worker", "CacheStorage"),
+	filepath.Join("Service Worker", "ScriptCache"),
+}
+
+// firefoxProfileCaches are the cache dirs inside a Firefox profile
+var firefoxProfileCaches = []string{"cache2", "startupCache"}
+
+// browserProfile is one profile's caches found during Scan
+type browserProfile struct {
+	browser browserDef
+	dirs    []string
+}
+
+// BrowserCleaner enumerates the profiles of Chromium and Firefox based
+// browsers (native, Flatpak and Snap) and reports the caches of each profile
+// separately. Browsers that are running are left alone.
+type BrowserCleaner struct {
+	detailSet
+
+	profiles map[string]browserProfile
+}
 
 func (c *BrowserCleaner) Name() string {
 	return "Browser Caches"
@@ -21,50 +89,159 @@ func (c *BrowserCleaner) Scan() (int64, error) {
 		return 0, err
 	}
 
-	targets := []string{
-		filepath.Join(home, ".cache", "google-chrome"),
-		filepath.Join(home, ".cache", "chromium"),
-		filepath.Join(home, ".cache", "mozilla", "firefox"),
-		filepath.Join(home, ".cache", "BraveSoftware"),
+	procs := SnapshotProcesses()
+	c.found = nil
+	c.profiles = make(map[string]browserProfile)
+
+	for _, b := range browserDefs {
+		config := filepath.Join(home, b.config)
+		cache := filepath.Join(home, b.cache)
+
+		var profiles map[string]string // profile dir name -> display name
+		var candidates []string
+		if b.firefox {
+			profiles = firefoxProfiles(config)
+			candidates = firefoxProfileCaches
+		} else {
+			profiles = chromiumProfiles(config)
+			candidates = chromiumProfileCaches
+		}
+
+		isRunning := procs.Running(b.procs...)
+		for _, dir := range sortedKeys(profiles) {
+			var dirs []string
+			var size int64
+			for _, base := range []string{config, cache} {
+				for _, sub := range candidates {
+					p := filepath.Join(base, dir, sub)
+					if !dirExists(p) {
+						continue
+					}
+					s, _ := simpleDirScan(p)
+					dirs = append(dirs, p)
+					size += s
+				}
+			}
+			if size == 0 {
+				continue
+			}
+
+			key := filepath.Join(config, dir)
+			detail := FileDetail{
+				Path:     key,
+				Size:     size,
+				Label:    b.name + " / " + profiles[dir],
+				Selected: true,
+			}
+			if isRunning {
+				detail.Warning = "owner app running"
+				detail.Selected = false
+			}
+			c.profiles[key] = browserProfile{browser: b, dirs: dirs}
+			c.found = append(c.found, detail)
+		}
 	}
+	return totalSize(c.found), nil
+}
 
-	var size int64
-	for _, t := range targets {
-		filepath.Walk(t, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
+// chromiumProfiles reads the profile list from "Local State", falling back
+// to the "Default" and "Profile N" dirs when it is missing. Opera keeps its
+// only profile in the user data dir itself, which then has "Preferences".
+func chromiumProfiles(userDataDir string) map[string]string {
+	profiles := make(map[string]string)
+
+	var state struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	if data, err := os.ReadFile(filepath.Join(userDataDir, "Local State")); err == nil {
+		if json.Unmarshal(data, &state) == nil {
+			for dir, info := range state.Profile.InfoCache {
+				profiles[dir] = info.Name + " (" + dir + ")"
 			}
-			if !info.IsDir() {
-				size += info.Size()
+		}
+	}
+
+	if len(profiles) == 0 {
+		dirs, _ := filepath.Glob(filepath.Join(userDataDir, "Profile *"))
+		dirs = append(dirs, filepath.Join(userDataDir, "Default"))
+		for _, d := range dirs {
+			if dirExists(d) {
+				profiles[filepath.Base(d)] = filepath.Base(d)
 			}
-			return nil
-		})
+		}
 	}
-	return size, nil
+	if _, err := os.Stat(filepath.Join(userDataDir, "Preferences")); err == nil {
+		profiles[""] = "Default"
+	}
+	return profiles
 }
 
-func (c *BrowserCleaner) Clean() error {
-	home, err := os.UserHomeDir()
+// firefoxProfiles parses profiles.ini and returns the relative profile paths
+func firefoxProfiles(root string) map[string]string {
+	profiles := make(map[string]string)
+
+	f, err := os.Open(filepath.Join(root, "profiles.ini"))
 	if err != nil {
-		return err
+		return profiles
+	}
+	defer f.Close()
+
+	type section struct{ name, path string }
+	var sections []section
+	inProfile := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inProfile = strings.HasPrefix(line, "[Profile")
+			if inProfile {
+				sections = append(sections, section{})
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inProfile || !ok {
+			continue
+		}
+		switch key {
+		case "Name":
+			sections[len(sections)-1].name = value
+		case "Path":
+			sections[len(sections)-1].path = value
+		}
 	}
 
-	// For browsers, we usually want to delete the Cache folder inside the profile
-	// But simply verifying the detailed structure is hard (random profile names).
-	// Deleting ~/.cache/google-chrome is usually safe (it regenerates).
-	
-	targets := []string{
-		filepath.Join(home, ".cache", "google-chrome"),
-		filepath.Join(home, ".cache", "chromium"),
-		// Firefox is tricky: ~/.cache/mozilla/firefox/PROFILE/cache2
-		// We can try to walk and find "cache2" dirs?
-		// Or just nuke ~/.cache/mozilla/firefox which contains cache data usually separated from user profile data (which is in ~/.mozilla)
-		filepath.Join(home, ".cache", "mozilla", "firefox"), 
-		filepath.Join(home, ".cache", "BraveSoftware"),
+	for _, s := range sections {
+		// Absolute paths (IsRelative=0) have no mirror in the cache dir, so
+		// they are made relative to root where possible and skipped otherwise
+		path := s.path
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(root, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			path = rel
+		}
+		if path != "" {
+			profiles[path] = s.name + " (" + path + ")"
+		}
 	}
+	return profiles
+}
 
-	for _, t := range targets {
-		os.RemoveAll(t)
+func (c *BrowserCleaner) Clean() error {
+	idle := c.idleTargets(func(procs *ProcessSnapshot, t FileDetail) bool {
+		p := c.profiles[t.Path]
+		return procs.Busy(p.browser.procs, p.dirs)
+	})
+	for _, t := range idle {
+		for _, dir := range c.profiles[t.Path].dirs {
+			_ = os.RemoveAll(dir)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,170 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultKeepBrowserRevisions is how many revisions of each downloaded
+// browser are kept when KeepRevisions is not set.
+const defaultKeepBrowserRevisions = 2
+
+// browserRevision is one downloaded browser build
+type browserRevision struct {
+	group   string
+	version string
+	path    string
+}
+
+// BrowserAutomationCleaner keeps the newest browser builds downloaded by
+// Playwright, Puppeteer and Cypress and reports older ones individually.
+type BrowserAutomationCleaner struct {
+	detailSet
+
+	// KeepRevisions is how many revisions of each browser to keep
+	KeepRevisions int
+}
+
+func (c *BrowserAutomationCleaner) Name() string {
+	return "Browser Automation Downloads"
+}
+
+func (c *BrowserAutomationCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *BrowserAutomationCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+	cacheDir := filepath.Join(home, ".cache")
+
+	keep := c.KeepRevisions
+	if keep <= 0 {
+		keep = defaultKeepBrowserRevisions
+	}
+
+	var revisions []browserRevision
+	revisions = append(revisions, playwrightRevisions(playwrightDir(cacheDir))...)
+	revisions = append(revisions, puppeteerRevisions(filepath.Join(cacheDir, "puppeteer"))...)
+	revisions = append(revisions, cypressRevisions(filepath.Join(cacheDir, "Cypress"))...)
+
+	groups := make(map[string][]browserRevision)
+	var order []string
+	for _, r := range revisions {
+		if _, ok := groups[r.group]; !ok {
+			order = append(order, r.group)
+		}
+		groups[r.group] = append(groups[r.group], r)
+	}
+
+	c.found = nil
+	for _, g := range order {
+		revs := groups[g]
+		if len(revs) <= keep {
+			continue
+		}
+		sort.Slice(revs, func(i, j int) bool { return compareVersions(revs[i].version, revs[j].version) > 0 })
+		for _, r := range revs[keep:] {
+			size, _ := simpleDirScan(r.path)
+			c.found = append(c.found, FileDetail{
+				Path:     r.path,
+				Size:     size,
+				Label:    r.group + " " + r.version + " (newer: " + revs[0].version + ")",
+				Selected: true,
+			})
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+func playwrightDir(cacheDir string) string {
+	if dir := os.Getenv("PLAYWRIGHT_BROWSERS_PATH"); dir != "" && dir != "0" {
+		return dir
+	}
+	return filepath.Join(cacheDir, "ms-playwright")
+}
+
+// playwrightRevisions parses "chromium-1091", "chromium_headless_shell-1091", "webkit-1944", ...
+func playwrightRevisions(root string) []browserRevision {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var revs []browserRevision
+	for _, e := range entries {
+		i := strings.LastIndex(e.Name(), "-")
+		if !e.IsDir() || i <= 0 || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		revs = append(revs, browserRevision{
+			group:   "Playwright " + e.Name()[:i],
+			version: e.Name()[i+1:],
+			path:    filepath.Join(root, e.Name()),
+		})
+	}
+	return revs
+}
+
+// puppeteerRevisions parses "<browser>/<platform>-<version>", e.g. chrome/linux-121.0.6167.85
+func puppeteerRevisions(root string) []browserRevision {
+	browsers, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var revs []browserRevision
+	for _, b := range browsers {
+		if !b.IsDir() {
+			continue
+		}
+		builds, _ := os.ReadDir(filepath.Join(root, b.Name()))
+		for _, build := range builds {
+			platform, version, ok := strings.Cut(build.Name(), "-")
+			if !build.IsDir() || !ok {
+				continue
+			}
+			revs = append(revs, browserRevision{
+				group:   "Puppeteer " + b.Name() + " (" + platform + ")",
+				version: version,
+				path:    filepath.Join(root, b.Name(), build.Name()),
+			})
+		}
+	}
+	return revs
+}
+
+// cypressRevisions parses the version directories of the Cypress binary cache
+func cypressRevisions(root string) []browserRevision {
+	if dir := os.Getenv("CYPRESS_CACHE_FOLDER"); dir != "" {
+		root = dir
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var revs []browserRevision
+	for _, e := range entries {
+		if !e.IsDir() || !semverPrefixRe.MatchString(e.Name()) {
+			continue
+		}
+		revs = append(revs, browserRevision{
+			group:   "Cypress",
+			version: e.Name(),
+			path:    filepath.Join(root, e.Name()),
+		})
+	}
+	return revs
+}
+
+func (c *BrowserAutomationCleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
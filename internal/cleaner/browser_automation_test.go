@@ -0,0 +1,50 @@
+package cleaner
+
+import "testing"
+
+func TestBrowserAutomationScan(t *testing.T) {
+	runScanCases(t, func() DetailCleaner { return &BrowserAutomationCleaner{} }, []scanCase{
+		{
+			name: "default cache dirs",
+			env:  map[string]string{"PLAYWRIGHT_BROWSERS_PATH": "", "CYPRESS_CACHE_FOLDER": ""},
+			files: map[string]string{
+				".cache/ms-playwright/chromium-1091/chrome":                "a",
+				".cache/ms-playwright/chromium-1105/chrome":                "a",
+				".cache/ms-playwright/chromium-1117/chrome":                "a",
+				".cache/ms-playwright/chromium_headless_shell-1091/shell":  "a",
+				".cache/ms-playwright/ffmpeg-1009/ffmpeg":                  "a",
+				".cache/ms-playwright/.links/abc":                          "a",
+				".cache/puppeteer/chrome/linux-119.0.6045.105/chrome":      "a",
+				".cache/puppeteer/chrome/linux-121.0.6167.85/chrome":       "a",
+				".cache/puppeteer/chrome/linux-120.0.6099.109/chrome":      "a",
+				".cache/puppeteer/chrome-headless-shell/linux-119.0/shell": "a",
+				".cache/Cypress/13.6.0/Cypress/cypress":                    "a",
+				".cache/Cypress/12.17.4/Cypress/cypress":                   "a",
+				".cache/Cypress/13.5.0/Cypress/cypress":                    "a",
+			},
+			want: map[string]bool{
+				".cache/ms-playwright/chromium-1091":           true,
+				".cache/puppeteer/chrome/linux-119.0.6045.105": true,
+				".cache/Cypress/12.17.4":                       true,
+			},
+			labels: map[string]string{
+				".cache/ms-playwright/chromium-1091":           "Playwright chromium 1091 (newer: 1117)",
+				".cache/puppeteer/chrome/linux-119.0.6045.105": "Puppeteer chrome (linux) 119.0.6045.105 (newer: 121.0.6167.85)",
+				".cache/Cypress/12.17.4":                       "Cypress 12.17.4 (newer: 13.6.0)",
+			},
+		},
+		{
+			name: "custom locations",
+			env:  map[string]string{"PLAYWRIGHT_BROWSERS_PATH": "$HOME/pw", "CYPRESS_CACHE_FOLDER": "$HOME/cy"},
+			files: map[string]string{
+				"pw/webkit-1900/pw_run.sh":               "a",
+				"pw/webkit-1944/pw_run.sh":               "a",
+				"pw/webkit-2000/pw_run.sh":               "a",
+				".cache/ms-playwright/webkit-1800/run":   "a",
+				"cy/13.6.0/Cypress/cypress":              "a",
+				".cache/Cypress/12.17.4/Cypress/cypress": "a",
+			},
+			want: map[string]bool{"pw/webkit-1900": true},
+		},
+	})
+}
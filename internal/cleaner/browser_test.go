@@ -0,0 +1,51 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBrowserProfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"chrome/Local State": `{"profile": {"info_cache": {"Default": {"name": "Person 1"}, "Profile 2": {"name": "Work"}}}}`,
+		"opera/Preferences":  "{}",
+		"firefox/profiles.ini": "[General]\nStartWithLastProfile=1\n\n" +
+			"[Profile0]\nName=default-release\nIsRelative=1\nPath=abcd.default-release\n\n" +
+			"[Profile1]\nName=moved\nIsRelative=0\nPath=" + filepath.Join(dir, "firefox", "Profiles", "efgh.moved") + "\n\n" +
+			"[Profile2]\nName=elsewhere\nIsRelative=0\nPath=/mnt/ffprofile\n\n" +
+			"[Install4F96D1932A9F858E]\nDefault=abcd.default-release\n",
+	})
+
+	tests := []struct {
+		name string
+		got  map[string]string
+		want map[string]string
+	}{
+		{"chromium", chromiumProfiles(filepath.Join(dir, "chrome")), map[string]string{
+			"Default":   "Person 1 (Default)",
+			"Profile 2": "Work (Profile 2)",
+		}},
+		{"opera", chromiumProfiles(filepath.Join(dir, "opera")), map[string]string{"": "Default"}},
+		{"firefox", firefoxProfiles(filepath.Join(dir, "firefox")), map[string]string{
+			"abcd.default-release":                  "default-release (abcd.default-release)",
+			filepath.Join("Profiles", "efgh.moved"): "moved (" + filepath.Join("Profiles", "efgh.moved") + ")",
+		}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s profiles = %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if tt.got[k] != v {
+				t.Errorf("%s profile %q = %q, want %q", tt.name, k, tt.got[k], v)
+			}
+		}
+	}
+
+	p := &ProcessSnapshot{names: map[string]bool{"chrome": true}, paths: []string{"/opt/google/chrome-beta/chrome"}}
+	if p.Running("/opt/google/chrome/chrome") || !p.Running("/opt/google/chrome-beta/chrome") {
+		t.Error("Running() does not tell Chrome channels apart")
+	}
+}
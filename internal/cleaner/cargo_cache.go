@@ -1,11 +1,35 @@
 package cleaner
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-type CargoCacheCleaner struct{}
+// defaultCargoKeepVersions is how many versions of each crate archive are
+// kept when KeepVersions is not set.
+const defaultCargoKeepVersions = 2
+
+// cargoLockMaxDepth is how deep below a project root Cargo.lock files are
+// looked for
+const cargoLockMaxDepth = 5
+
+// CargoCacheCleaner prunes ~/.cargo the way cargo-cache does: extracted
+// sources are dropped (cargo re-extracts them from the kept .crate archives),
+// only the newest versions of each crate archive are kept, and git checkouts
+// no longer referenced by any Cargo.lock are removed. Checkouts are left alone
+// when no Cargo.lock is found at all, and the git db clones always are. The
+// registry index is listed for its size but not selected.
+type CargoCacheCleaner struct {
+	detailSet
+
+	// KeepVersions is how many versions of each crate archive to keep
+	KeepVersions int
+	// ProjectRoots are searched for Cargo.lock files. Defaults to the home directory.
+	ProjectRoots []string
+}
 
 func (c *CargoCacheCleaner) Name() string {
 	return "Cargo Cache (Rust)"
@@ -15,45 +39,218 @@ func (c *CargoCacheCleaner) RequiresRoot() bool {
 	return false
 }
 
-func (c *CargoCacheCleaner) Scan() (int64, error) {
+func cargoHome() (string, error) {
+	if dir := os.Getenv("CARGO_HOME"); dir != "" {
+		return dir, nil
+	}
 	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cargo"), nil
+}
+
+func (c *CargoCacheCleaner) Scan() (int64, error) {
+	root, err := cargoHome()
 	if err != nil {
 		return 0, err
 	}
 
-	var size int64
-	paths := []string{
-		filepath.Join(home, ".cargo", "registry", "cache"),
-		filepath.Join(home, ".cargo", "registry", "src"),
-		filepath.Join(home, ".cargo", "git", "db"),
-		filepath.Join(home, ".cargo", "git", "checkouts"),
+	c.found = nil
+
+	// Extracted sources are rebuilt from the .crate archives we keep
+	if entries, err := os.ReadDir(filepath.Join(root, "registry", "src")); err == nil {
+		for _, e := range entries {
+			c.add(filepath.Join(root, "registry", "src", e.Name()), "Extracted sources ("+e.Name()+")", true)
+		}
 	}
+	c.oldCrateArchives(filepath.Join(root, "registry", "cache"))
+	c.unreferencedCheckouts(root)
 
-	for _, p := range paths {
-		s, _ := simpleDirScan(p)
-		size += s
+	// The registry index is part of the footprint, but removing it only
+	// makes the next build fetch it again
+	if entries, err := os.ReadDir(filepath.Join(root, "registry", "index")); err == nil {
+		for _, e := range entries {
+			c.add(filepath.Join(root, "registry", "index", e.Name()), "Registry index ("+e.Name()+", fetched again on the next build)", false)
+		}
 	}
 
+	var size int64
+	for _, d := range c.found {
+		if d.Selected {
+			size += d.Size
+		}
+	}
 	return size, nil
 }
 
-func (c *CargoCacheCleaner) Clean() error {
-	home, err := os.UserHomeDir()
+// add lists path unless it is empty
+func (c *CargoCacheCleaner) add(path, label string, selected bool) {
+	size, _ := simpleDirScan(path)
+	if size == 0 {
+		return
+	}
+	c.found = append(c.found, FileDetail{Path: path, Size: size, Label: label, Selected: selected})
+}
+
+// oldCrateArchives lists the .crate files beyond the newest KeepVersions of
+// each crate
+func (c *CargoCacheCleaner) oldCrateArchives(cacheDir string) {
+	keep := c.KeepVersions
+	if keep <= 0 {
+		keep = defaultCargoKeepVersions
+	}
+
+	registries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+
+	for _, reg := range registries {
+		regDir := filepath.Join(cacheDir, reg.Name())
+		files, err := os.ReadDir(regDir)
+		if err != nil {
+			continue
+		}
+
+		versions := make(map[string][]string) // crate -> versions
+		for _, f := range files {
+			name, version, ok := splitCrateFileName(f.Name())
+			if !ok {
+				continue
+			}
+			versions[name] = append(versions[name], version)
+		}
+
+		for _, name := range sortedKeys(versions) {
+			vs := versions[name]
+			if len(vs) <= keep {
+				continue
+			}
+			sort.Slice(vs, func(i, j int) bool { return compareVersions(vs[i], vs[j]) > 0 })
+			for _, v := range vs[keep:] {
+				c.add(filepath.Join(regDir, name+"-"+v+".crate"), name+" "+v+" archive (newest is "+vs[0]+")", true)
+			}
+		}
+	}
+}
+
+// splitCrateFileName splits "serde-1.0.190.crate" into "serde" and "1.0.190"
+func splitCrateFileName(file string) (string, string, bool) {
+	base, ok := strings.CutSuffix(file, ".crate")
+	if !ok {
+		return "", "", false
+	}
+	return splitNameVersion(base)
+}
+
+// unreferencedCheckouts lists git checkouts whose revision is not pinned by
+// any Cargo.lock. Without a single Cargo.lock nothing counts as unreferenced:
+// the projects may live outside ProjectRoots.
+func (c *CargoCacheCleaner) unreferencedCheckouts(root string) {
+	checkoutsDir := filepath.Join(root, "git", "checkouts")
+	repos, err := os.ReadDir(checkoutsDir)
 	if err != nil {
-		return err
+		return
+	}
+
+	revs, lockfiles := c.referencedGitRevs()
+	if lockfiles == 0 {
+		return
+	}
+
+	for _, repo := range repos {
+		repoDir := filepath.Join(checkoutsDir, repo.Name())
+		checkouts, err := os.ReadDir(repoDir)
+		if err != nil {
+			continue
+		}
+
+		for _, co := range checkouts {
+			if !isReferencedRev(co.Name(), revs) {
+				c.add(filepath.Join(repoDir, co.Name()), "git checkout "+repo.Name()+" "+co.Name()+" (in no Cargo.lock)", true)
+			}
+		}
+	}
+}
+
+func isReferencedRev(short string, revs []string) bool {
+	for _, r := range revs {
+		if strings.HasPrefix(r, short) {
+			return true
+		}
 	}
+	return false
+}
 
-	paths := []string{
-		filepath.Join(home, ".cargo", "registry", "cache"),
-		filepath.Join(home, ".cargo", "registry", "src"),
-		filepath.Join(home, ".cargo", "git", "db"),
-		filepath.Join(home, ".cargo", "git", "checkouts"),
+// referencedGitRevs collects the commit hashes of all git dependencies pinned
+// in Cargo.lock files below the project roots, and how many it read
+func (c *CargoCacheCleaner) referencedGitRevs() ([]string, int) {
+	roots := c.ProjectRoots
+	if len(roots) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, 0
+		}
+		roots = []string{home}
 	}
 
-	for _, p := range paths {
-		// Just blow away the cache folders directly. Cargo will re-download on demand.
-		_ = os.RemoveAll(p)
+	var revs []string
+	lockfiles := 0
+	for _, root := range roots {
+		rootDepth := strings.Count(filepath.Clean(root), string(filepath.Separator))
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				name := info.Name()
+				if path != root && (strings.HasPrefix(name, ".") || name == "target" || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				if strings.Count(path, string(filepath.Separator))-rootDepth >= cargoLockMaxDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Name() == "Cargo.lock" {
+				lockfiles++
+				revs = append(revs, parseLockfileGitRevs(path)...)
+			}
+			return nil
+		})
 	}
+	return revs, lockfiles
+}
 
+// parseLockfileGitRevs extracts commits from lines like
+// source = "git+https://github.com/foo/bar?branch=main#0123abcd..."
+func parseLockfileGitRevs(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var revs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		src, ok := strings.CutPrefix(line, "source = \"git+")
+		if !ok {
+			continue
+		}
+		if _, rev, ok := strings.Cut(strings.TrimSuffix(src, "\""), "#"); ok && rev != "" {
+			revs = append(revs, rev)
+		}
+	}
+	return revs
+}
+
+func (c *CargoCacheCleaner) Clean() error {
+	for _, t := range c.targets() {
+		// Cargo re-downloads or re-extracts anything it needs on demand
+		_ = os.RemoveAll(t.Path)
+	}
 	return nil
 }
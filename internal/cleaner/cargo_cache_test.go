@@ -0,0 +1,69 @@
+package cleaner
+
+import "testing"
+
+func TestSplitCrateFileName(t *testing.T) {
+	tests := []struct {
+		file, name, version string
+	}{
+		{"serde-1.0.190.crate", "serde", "1.0.190"},
+		{"sha-1-0.10.1.crate", "sha-1", "0.10.1"},
+		{"windows-sys-0.48.0-rc.1.crate", "windows-sys", "0.48.0-rc.1"},
+	}
+	for _, tt := range tests {
+		name, version, ok := splitCrateFileName(tt.file)
+		if !ok || name != tt.name || version != tt.version {
+			t.Errorf("splitCrateFileName(%q) = %q, %q, %v", tt.file, name, version, ok)
+		}
+	}
+}
+
+func TestCargoCacheScan(t *testing.T) {
+	cache := map[string]string{
+		".cargo/registry/index/github.com-1ecc/config.json":         "index",
+		".cargo/registry/src/github.com-1ecc/serde-1.0.190/lib.rs":  "src",
+		".cargo/registry/cache/github.com-1ecc/serde-1.0.188.crate": "old",
+		".cargo/registry/cache/github.com-1ecc/serde-1.0.189.crate": "keep",
+		".cargo/registry/cache/github.com-1ecc/serde-1.0.190.crate": "keep",
+		".cargo/git/db/foo-1234/HEAD":                               "db",
+		".cargo/git/checkouts/foo-1234/0123abc/lib.rs":              "used",
+		".cargo/git/checkouts/foo-1234/fedcba9/lib.rs":              "stale",
+	}
+	withLock := map[string]string{
+		"src/app/Cargo.lock": "source = \"git+https://example.com/foo?branch=main#0123abcdef\"\n",
+	}
+	for k, v := range cache {
+		withLock[k] = v
+	}
+
+	// The index is listed but neither selected nor counted
+	runScanCases(t, func() DetailCleaner { return &CargoCacheCleaner{} }, []scanCase{
+		{
+			name:  "no Cargo.lock",
+			files: cache,
+			env:   map[string]string{"CARGO_HOME": "$HOME/.cargo"},
+			want: map[string]bool{
+				".cargo/registry/src/github.com-1ecc":                       true,
+				".cargo/registry/cache/github.com-1ecc/serde-1.0.188.crate": true,
+				".cargo/registry/index/github.com-1ecc":                     false,
+			},
+			size: int64(len("src") + len("old")),
+		},
+		{
+			name:  "with Cargo.lock",
+			files: withLock,
+			env:   map[string]string{"CARGO_HOME": "$HOME/.cargo"},
+			want: map[string]bool{
+				".cargo/registry/src/github.com-1ecc":                       true,
+				".cargo/registry/cache/github.com-1ecc/serde-1.0.188.crate": true,
+				".cargo/registry/index/github.com-1ecc":                     false,
+				".cargo/git/checkouts/foo-1234/fedcba9":                     true,
+			},
+			labels: map[string]string{
+				".cargo/registry/cache/github.com-1ecc/serde-1.0.188.crate": "serde 1.0.188 archive (newest is 1.0.190)",
+				".cargo/git/checkouts/foo-1234/fedcba9":                     "git checkout foo-1234 fedcba9 (in no Cargo.lock)",
+			},
+			size: int64(len("src") + len("old") + len("stale")),
+		},
+	})
+}
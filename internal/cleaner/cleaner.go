@@ -11,3 +11,92 @@ type Cleaner interface {
 	// RequiresRoot returns true if the cleaner requires root privileges to operate
 	RequiresRoot() bool
 }
+
+// DetailCleaner is implemented by cleaners that report individual entries
+// (files, caches, versions...) the user can pick from before cleaning
+type DetailCleaner interface {
+	Cleaner
+	// Details returns the entries found by the last Scan
+	Details() []FileDetail
+	// SetFilesToClean restricts Clean to the entries with the given paths
+	SetFilesToClean(paths []string)
+}
+
+// FileDetail is a single entry reported by a DetailCleaner
+type FileDetail struct {
+	Path string
+	Size int64
+	// Label is shown instead of Path when set
+	Label string
+	// Selected marks entries that are cleaned unless the user deselects them
+	Selected bool
+	// Warning explains why deleting the entry is risky right now, e.g.
+	// "in use". Such entries are never preselected.
+	Warning string
+}
+
+// GroupCleaner is implemented by cleaners that report groups of identical
+// files, of which the user keeps one copy per group
+type GroupCleaner interface {
+	Cleaner
+	// Groups returns the duplicate groups found by the last Scan
+	Groups() []DuplicateGroup
+	// SetKeep sets the copy to keep per group ID; groups missing from keep
+	// are left alone
+	SetKeep(keep map[string]string)
+	// Mode returns how the redundant copies are disposed of
+	Mode() DedupMode
+	SetMode(mode DedupMode)
+}
+
+// DuplicateGroup is a set of files with identical content
+type DuplicateGroup struct {
+	// ID is the content hash
+	ID   string
+	Size int64
+	// Files are ordered oldest first
+	Files []string
+	// Keep is the copy kept by default
+	Keep string
+}
+
+// DedupMode is what happens to the redundant copies of a duplicate group
+type DedupMode int
+
+const (
+	// DedupDelete removes the copies
+	DedupDelete DedupMode = iota
+	// DedupHardlink replaces the copies with hard links to the kept file
+	DedupHardlink
+	// DedupReflink replaces the copies with copy-on-write clones (Btrfs, XFS)
+	DedupReflink
+)
+
+func (m DedupMode) String() string {
+	switch m {
+	case DedupHardlink:
+		return "hardlink"
+	case DedupReflink:
+		return "reflink"
+	}
+	return "delete"
+}
+
+// ParseDedupMode parses the String form of a DedupMode
+func ParseDedupMode(s string) (DedupMode, bool) {
+	for _, m := range []DedupMode{DedupDelete, DedupHardlink, DedupReflink} {
+		if m.String() == s {
+			return m, true
+		}
+	}
+	return DedupDelete, false
+}
+
+// ProgressCleaner is implemented by cleaners whose Clean takes long enough
+// to be worth reporting on. Progress is called from other goroutines while
+// Clean runs.
+type ProgressCleaner interface {
+	Cleaner
+	// Progress describes what Clean is doing right now, e.g. "3/12 repos"
+	Progress() string
+}
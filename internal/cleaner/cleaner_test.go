@@ -9,10 +9,28 @@ func TestCleanerInterfaces(t *testing.T) {
 		&AptCleaner{},
 		&GoCacheCleaner{},
 		&DynamicCacheCleaner{},
-		&NpmCacheCleaner{},
+		&JSCacheCleaner{},
 		&FlatpakCleaner{},
 		&TmpCleaner{},
 		&CargoCacheCleaner{},
+		&LanguageCacheCleaner{},
+		&CompilerCacheCleaner{},
+		&BazelCleaner{},
+		&MLModelCleaner{},
+		&BrowserAutomationCleaner{},
+		&RemoteIDECleaner{},
+		&JetBrainsCleaner{},
+		&CrashCleaner{},
+		&LeftoverCleaner{},
+		&DuplicateCleaner{},
+		&EmptyDirCleaner{},
+		&DownloadsCleaner{},
+		&AppImageCleaner{},
+		&GitRepoCleaner{},
+		&VMCleaner{},
+		&LocalKubeCleaner{},
+		&SteamCleaner{},
+		&NixCleaner{},
 		&AppCacheCleaner{},
 		&BrowserCleaner{},
 		&DockerCleaner{},
@@ -32,3 +50,4 @@ func TestCleanerInterfaces(t *testing.T) {
 		_ = c.RequiresRoot()
 	}
 }
+
@@ -0,0 +1,254 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compilerCache is the state of one compiler cache found during Scan
+type compilerCache struct {
+	tool    string
+	dir     string
+	size    int64
+	maxSize int64
+	target  int64
+	hitRate float64 // percent, negative if unknown
+}
+
+// CompilerCacheCleaner trims ccache and sccache down to a target size instead
+// of deleting them, so the most recently used objects survive.
+type CompilerCacheCleaner struct {
+	detailSet
+
+	// TargetSize is the size each cache is trimmed to. Defaults to half of
+	// the cache's configured maximum.
+	TargetSize int64
+	// EvictOlderThan additionally drops entries not used for this long
+	EvictOlderThan time.Duration
+
+	caches map[string]compilerCache
+}
+
+func (c *CompilerCacheCleaner) Name() string {
+	return "Compiler Caches (ccache/sccache)"
+}
+
+func (c *CompilerCacheCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *CompilerCacheCleaner) Scan() (int64, error) {
+	c.found = nil
+	c.caches = make(map[string]compilerCache)
+
+	for _, probe := range []func() (compilerCache, bool){probeCcache, probeSccache} {
+		cc, ok := probe()
+		if !ok || cc.size == 0 {
+			continue
+		}
+
+		cc.target = c.TargetSize
+		if cc.target <= 0 {
+			// An unlimited cache (max size 0) is halved from its current size
+			limit := cc.maxSize
+			if limit <= 0 {
+				limit = cc.size
+			}
+			cc.target = limit / 2
+		}
+		reclaimable := cc.size - cc.target
+		if reclaimable <= 0 && c.EvictOlderThan == 0 {
+			continue
+		}
+		if reclaimable < 0 {
+			reclaimable = 0
+		}
+
+		hits := "hit rate n/a"
+		if cc.hitRate >= 0 {
+			hits = fmt.Sprintf("%.0f%% hits", cc.hitRate)
+		}
+		limit := "unlimited"
+		if cc.maxSize > 0 {
+			limit = formatSize(cc.maxSize) + " max"
+		}
+		c.caches[cc.dir] = cc
+		c.found = append(c.found, FileDetail{
+			Path: cc.dir,
+			Size: reclaimable,
+			Label: fmt.Sprintf("%s: %s of %s, %s, trim to %s",
+				cc.tool, formatSize(cc.size), limit, hits, formatSize(cc.target)),
+			Selected: true,
+		})
+	}
+	return totalSize(c.found), nil
+}
+
+// probeCcache reads ccache's configuration and statistics
+func probeCcache() (compilerCache, bool) {
+	if _, err := exec.LookPath("ccache"); err != nil {
+		return compilerCache{}, false
+	}
+
+	cc := compilerCache{tool: "ccache", hitRate: -1}
+	if out, err := exec.Command("ccache", "--get-config", "cache_dir").Output(); err == nil {
+		cc.dir = strings.TrimSpace(string(out))
+	}
+	if cc.dir == "" {
+		return compilerCache{}, false
+	}
+	if out, err := exec.Command("ccache", "--get-config", "max_size").Output(); err == nil {
+		cc.maxSize = parseDecimalSize(string(out))
+	}
+
+	// --print-stats emits tab separated "key value" lines
+	if out, err := exec.Command("ccache", "--print-stats").Output(); err == nil {
+		stats := make(map[string]int64)
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 {
+				stats[fields[0]], _ = strconv.ParseInt(fields[1], 10, 64)
+			}
+		}
+		hits := stats["direct_cache_hit"] + stats["preprocessed_cache_hit"]
+		if total := hits + stats["cache_miss"]; total > 0 {
+			cc.hitRate = float64(hits) * 100 / float64(total)
+		}
+	}
+
+	cc.size, _ = simpleDirScan(cc.dir)
+	return cc, true
+}
+
+// probeSccache reads sccache's local disk cache location and statistics
+func probeSccache() (compilerCache, bool) {
+	if _, err := exec.LookPath("sccache"); err != nil {
+		return compilerCache{}, false
+	}
+
+	cc := compilerCache{tool: "sccache", hitRate: -1, maxSize: 10 * 1024 * 1024 * 1024}
+	cc.dir = os.Getenv("SCCACHE_DIR")
+	if cc.dir == "" {
+		cacheHome := os.Getenv("XDG_CACHE_HOME")
+		if cacheHome == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return compilerCache{}, false
+			}
+			cacheHome = filepath.Join(home, ".cache")
+		}
+		cc.dir = filepath.Join(cacheHome, "sccache")
+	}
+	if v := os.Getenv("SCCACHE_CACHE_SIZE"); v != "" {
+		cc.maxSize = parseByteSize(v)
+	}
+
+	// Lines look like "Cache hits rate    73.21 %" and "Max cache size    10 GiB"
+	if out, err := exec.Command("sccache", "--show-stats").Output(); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if v, ok := strings.CutPrefix(line, "Cache hits rate"); ok {
+				fmt.Sscanf(strings.TrimSpace(v), "%f", &cc.hitRate)
+			} else if v, ok := strings.CutPrefix(line, "Max cache size"); ok {
+				if size := parseByteSize(v); size > 0 {
+					cc.maxSize = size
+				}
+			}
+		}
+	}
+
+	cc.size, _ = simpleDirScan(cc.dir)
+	return cc, true
+}
+
+func (c *CompilerCacheCleaner) Clean() error {
+	var errs []string
+	for _, t := range c.targets() {
+		cc, ok := c.caches[t.Path]
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch cc.tool {
+		case "ccache":
+			err = c.trimCcache(cc)
+		case "sccache":
+			// The server keeps its own LRU index, so stop it before touching files
+			_ = exec.Command("sccache", "--stop-server").Run()
+			err = trimDirLRU(cc.dir, cc.target, c.EvictOlderThan)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", cc.tool, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// trimCcache lets ccache evict entries itself. CCACHE_MAXSIZE only applies
+// to this invocation, so the user's configured limit is left untouched.
+func (c *CompilerCacheCleaner) trimCcache(cc compilerCache) error {
+	if c.EvictOlderThan > 0 {
+		age := fmt.Sprintf("%ds", int64(c.EvictOlderThan.Seconds()))
+		if err := exec.Command("ccache", "--evict-older-than", age).Run(); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command("ccache", "--cleanup")
+	cmd.Env = append(os.Environ(), ccacheMaxSizeEnv(cc.target))
+	return cmd.Run()
+}
+
+// ccacheMaxSizeEnv returns the CCACHE_MAXSIZE setting for a target size in
+// bytes. A plain number means gigabytes to ccache and 0 means unlimited, so
+// the size is given in kilobytes, at least one.
+func ccacheMaxSizeEnv(target int64) string {
+	return fmt.Sprintf("CCACHE_MAXSIZE=%dK", max(target/1000, 1))
+}
+
+// trimDirLRU deletes the least recently accessed files below dir until it
+// fits into target bytes, after dropping anything older than maxAge.
+func trimDirLRU(dir string, target int64, maxAge time.Duration) error {
+	type entry struct {
+		path  string
+		size  int64
+		atime time.Time
+	}
+
+	var entries []entry
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		entries = append(entries, entry{path, info.Size(), accessTime(info)})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].atime.Before(entries[j].atime) })
+
+	for _, e := range entries {
+		tooOld := maxAge > 0 && time.Since(e.atime) > maxAge
+		if total <= target && !tooOld {
+			continue
+		}
+		if err := os.Remove(e.path); err == nil {
+			total -= e.size
+		}
+	}
+	return nil
+}
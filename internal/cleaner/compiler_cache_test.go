@@ -0,0 +1,60 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeCcache puts a ccache script on PATH that reports dir as its cache,
+// maxSize as its limit and 3 hits out of 4 lookups
+func fakeCcache(t *testing.T, dir, maxSize string) {
+	t.Helper()
+	bin := t.TempDir()
+	script := `#!/bin/sh
+case "$1 $2" in
+"--get-config cache_dir") echo ` + dir + ` ;;
+"--get-config max_size") echo ` + maxSize + ` ;;
+"--print-stats ") printf 'direct_cache_hit\t2\npreprocessed_cache_hit\t1\ncache_miss\t1\n' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "ccache"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+}
+
+func TestCompilerCacheScan(t *testing.T) {
+	cache := t.TempDir()
+	writeTestFiles(t, cache, map[string]string{"a/b.o": "objects!"})
+	fakeCcache(t, cache, "0")
+
+	c := &CompilerCacheCleaner{}
+	size, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.found) != 1 {
+		t.Fatalf("found %v, want the ccache entry", c.found)
+	}
+	// An unlimited cache is trimmed to half its size
+	d := c.found[0]
+	want := "ccache: " + formatSize(8) + " of unlimited, 75% hits, trim to " + formatSize(4)
+	if d.Path != cache || d.Label != want || !d.Selected || size != 4 {
+		t.Errorf("Scan() = %d, %+v, want label %q", size, d, want)
+	}
+}
+
+func TestCcacheMaxSizeEnv(t *testing.T) {
+	tests := map[int64]string{
+		500:           "CCACHE_MAXSIZE=1K",
+		0:             "CCACHE_MAXSIZE=1K",
+		1_500_000:     "CCACHE_MAXSIZE=1500K",
+		5_000_000_000: "CCACHE_MAXSIZE=5000000K",
+	}
+	for target, want := range tests {
+		if got := ccacheMaxSizeEnv(target); got != want {
+			t.Errorf("ccacheMaxSizeEnv(%d) = %q, want %q", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package cleaner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultCrashMaxAgeDays is how old a crash artifact must be before it is
+// preselected when MaxAgeDays is not set
+const defaultCrashMaxAgeDays = 7
+
+var (
+	coredumpDir = "/var/lib/systemd/coredump"
+	apportDir   = "/var/crash"
+)
+
+// crashReport is one core dump or apport report found during Scan
+type crashReport struct {
+	path string
+	// extra holds companion files removed together with path
+	extra []string
+	exe   string
+	pid   int
+	time  time.Time
+	size  int64
+	kind  string
+}
+
+// CrashCleaner lists systemd-coredump core dumps and apport crash reports
+// individually, with the crashing executable, PID and time. Reports older
+// than MaxAgeDays are preselected; with KeepNewest the most recent report of
+// each executable is always kept.
+type CrashCleaner struct {
+	detailSet
+
+	// MaxAgeDays is how old a report must be before it is preselected
+	MaxAgeDays int
+	// KeepNewest never preselects the most recent report per executable
+	KeepNewest bool
+
+	extras map[string][]string
+}
+
+func (c *CrashCleaner) Name() string {
+	return "Crash Reports & Core Dumps"
+}
+
+func (c *CrashCleaner) RequiresRoot() bool {
+	// Apport reports of the current user can be removed without root
+	return false
+}
+
+func (c *CrashCleaner) Scan() (int64, error) {
+	maxAgeDays := c.MaxAgeDays
+	if maxAgeDays <= 0 {
+		maxAgeDays = defaultCrashMaxAgeDays
+	}
+	maxAge := time.Duration(maxAgeDays) * 24 * time.Hour
+
+	reports := append(scanCoredumps(coredumpDir), scanApportReports(apportDir)...)
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].exe != reports[j].exe {
+			return reports[i].exe < reports[j].exe
+		}
+		return reports[i].time.After(reports[j].time)
+	})
+
+	c.found = nil
+	c.extras = make(map[string][]string)
+	for i, r := range reports {
+		newest := i == 0 || reports[i-1].exe != r.exe
+		label := r.exe
+		if r.pid > 0 {
+			label += " pid " + strconv.Itoa(r.pid)
+		}
+		label += " at " + r.time.Format("2006-01-02 15:04") + " (" + r.kind + ")"
+
+		detail := FileDetail{
+			Path:     r.path,
+			Size:     r.size,
+			Selected: time.Since(r.time) > maxAge,
+		}
+		if newest && c.KeepNewest {
+			label += " newest"
+			detail.Selected = false
+		}
+		detail.Label = label
+		if !removable(r.path) {
+			detail.Warning = "needs root"
+			detail.Selected = false
+		}
+
+		c.extras[r.path] = r.extra
+		c.found = append(c.found, detail)
+	}
+	return totalSize(c.found), nil
+}
+
+// scanCoredumps parses the systemd-coredump file names,
+// core.<comm>.<uid>.<boot id>.<pid>.<usec>[.zst|.xz|.lz4]. The comm is
+// truncated to 15 characters, so the full executable path is taken from
+// coredumpctl when it is available.
+func scanCoredumps(dir string) []crashReport {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	exes := coredumpctlExes()
+
+	var reports []crashReport
+	for _, e := range entries {
+		fields := strings.Split(e.Name(), ".")
+		if e.IsDir() || len(fields) < 6 || fields[0] != "core" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		// systemd escapes dots and slashes in the comm as \x2e and \x2f
+		comm := strings.NewReplacer(`\x2e`, ".", `\x2f`, "/").Replace(fields[1])
+		pid, _ := strconv.Atoi(fields[4])
+		r := crashReport{
+			path: filepath.Join(dir, e.Name()),
+			exe:  comm,
+			pid:  pid,
+			time: info.ModTime(),
+			size: info.Size(),
+			kind: "core dump",
+		}
+		if usec, err := strconv.ParseInt(fields[5], 10, 64); err == nil {
+			r.time = time.UnixMicro(usec)
+		}
+		if exe, ok := exes[pid]; ok && filepath.Base(exe) == comm {
+			r.exe = exe
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+// coredumpctlExes maps the PIDs in the coredump journal to their executables
+func coredumpctlExes() map[int]string {
+	exes := make(map[int]string)
+	if _, err := exec.LookPath("coredumpctl"); err != nil {
+		return exes
+	}
+	out, err := exec.Command("coredumpctl", "list", "--json=short", "--no-pager").Output()
+	if err != nil {
+		return exes
+	}
+
+	var list []struct {
+		Pid int    `json:"pid"`
+		Exe string `json:"exe"`
+	}
+	if json.Unmarshal(out, &list) == nil {
+		for _, d := range list {
+			exes[d.Pid] = d.Exe
+		}
+	}
+	return exes
+}
+
+// scanApportReports reads the ExecutablePath, Date and Pid of each
+// <path_with_underscores>.<uid>.crash report. Reports we may not read fall
+// back to the executable encoded in the file name and the file time.
+func scanApportReports(dir string) []crashReport {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.crash"))
+
+	var reports []crashReport
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		base := strings.TrimSuffix(filepath.Base(path), ".crash")
+		if i := strings.LastIndex(base, "."); i >= 0 {
+			base = base[:i]
+		}
+		r := crashReport{
+			path: path,
+			exe:  strings.ReplaceAll(base, "_", "/"),
+			time: info.ModTime(),
+			size: info.Size(),
+			kind: "apport",
+		}
+		readApportHeader(path, &r)
+
+		// Upload markers written by whoopsie belong to the report
+		stem := strings.TrimSuffix(path, ".crash")
+		for _, ext := range []string{".upload", ".uploaded"} {
+			if fi, err := os.Stat(stem + ext); err == nil {
+				r.extra = append(r.extra, stem+ext)
+				r.size += fi.Size()
+			}
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+// readApportHeader fills in what the report itself says about the crash.
+// The fields of interest come before the large base64 encoded core dump.
+func readApportHeader(path string, r *crashReport) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "ExecutablePath: "):
+			r.exe = strings.TrimPrefix(line, "ExecutablePath: ")
+		case strings.HasPrefix(line, "Date: "):
+			if t, err := time.ParseInLocation("Mon Jan _2 15:04:05 2006", strings.TrimPrefix(line, "Date: "), time.Local); err == nil {
+				r.time = t
+			}
+		case strings.HasPrefix(line, " Pid:"):
+			// Continuation line of the ProcStatus field
+			r.pid, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, " Pid:")))
+		case strings.HasPrefix(line, "CoreDump: "):
+			return
+		}
+	}
+}
+
+// removable reports whether we may unlink path: its dir must be writable
+// and, in sticky dirs like /var/crash, the file must be ours
+func removable(path string) bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	dir := filepath.Dir(path)
+	if syscall.Access(dir, 2) != nil { // W_OK
+		return false
+	}
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	if dirInfo.Mode()&os.ModeSticky == 0 {
+		return true
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
+func (c *CrashCleaner) Clean() error {
+	for _, t := range c.targets() {
+		if err := os.Remove(t.Path); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		for _, p := range c.extras[t.Path] {
+			_ = os.Remove(p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package cleaner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanCoredumps(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		`core.my\x2eapp.1000.0123456789abcdef0123456789abcdef.4242.1760000000000000.zst`: "core",
+		"unrelated": "",
+	})
+
+	reports := scanCoredumps(dir)
+	if len(reports) != 1 {
+		t.Fatalf("scanCoredumps found %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.exe != "my.app" || r.pid != 4242 || !r.time.Equal(time.UnixMicro(1760000000000000)) {
+		t.Errorf("scanCoredumps = %+v", r)
+	}
+}
@@ -0,0 +1,252 @@
+package cleaner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDownloadsMinAgeDays is how old a download must be before it is
+// preselected when MinAgeDays is not set
+const defaultDownloadsMinAgeDays = 14
+
+// downloadCategories maps file suffixes to the category they are listed under.
+// Longer suffixes are matched first, so ".tar.gz" wins over ".gz".
+var downloadCategories = map[string]string{
+	".deb":        "package",
+	".rpm":        "package",
+	".flatpakref": "package",
+	".appimage":   "appimage",
+	".iso":        "disk image",
+	".img":        "disk image",
+	".qcow2":      "disk image",
+	".vmdk":       "disk image",
+	".run":        "installer",
+	".zip":        "archive",
+	".7z":         "archive",
+	".rar":        "archive",
+	".tar":        "archive",
+	".tar.gz":     "archive",
+	".tgz":        "archive",
+	".tar.xz":     "archive",
+	".txz":        "archive",
+	".tar.bz2":    "archive",
+	".tar.zst":    "archive",
+}
+
+// DownloadsCleaner lists installers, disk images and archives in the XDG
+// download dir by category. Archives whose extracted folder sits next to them
+// and packages that are already installed are preselected once older than
+// MinAgeDays, as is everything in Categories.
+type DownloadsCleaner struct {
+	detailSet
+
+	// MinAgeDays is how old a download must be before it is preselected
+	MinAgeDays int
+	// Categories are preselected as a whole ("package", "appimage", "disk
+	// image", "installer", "archive")
+	Categories []string
+}
+
+func (c *DownloadsCleaner) Name() string {
+	return "Old Installers & Archives (Downloads)"
+}
+
+func (c *DownloadsCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *DownloadsCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+	dir := xdgUserDirs(home)["DOWNLOAD"]
+	if dir == "" || dir == home {
+		return 0, nil
+	}
+
+	minAgeDays := c.MinAgeDays
+	if minAgeDays <= 0 {
+		minAgeDays = defaultDownloadsMinAgeDays
+	}
+	wholeCategory := make(map[string]bool)
+	for _, cat := range c.Categories {
+		wholeCategory[cat] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, nil
+	}
+
+	type download struct {
+		category string
+		detail   FileDetail
+	}
+	var downloads []download
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		category, suffix := downloadCategory(e.Name())
+		if category == "" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+
+		var reason string
+		switch category {
+		case "archive":
+			if extractedDir(path, suffix) != "" {
+				reason = "already extracted"
+			}
+		case "package":
+			if packageInstalled(path, suffix) {
+				reason = "installed"
+			}
+		}
+
+		days := int(time.Since(info.ModTime()).Hours() / 24)
+		old := days >= minAgeDays
+		label := "[" + category + "] " + e.Name() + " ("
+		if reason != "" {
+			label += reason + ", "
+		}
+		label += strconv.Itoa(days) + " days old)"
+
+		downloads = append(downloads, download{category, FileDetail{
+			Path:     path,
+			Size:     info.Size(),
+			Label:    label,
+			Selected: old && (reason != "" || wholeCategory[category]),
+		}})
+	}
+
+	// Grouped by category, biggest first
+	sort.SliceStable(downloads, func(i, j int) bool {
+		if downloads[i].category != downloads[j].category {
+			return downloads[i].category < downloads[j].category
+		}
+		return downloads[i].detail.Size > downloads[j].detail.Size
+	})
+	c.found = nil
+	for _, d := range downloads {
+		c.found = append(c.found, d.detail)
+	}
+	return totalSize(c.found), nil
+}
+
+// downloadCategory returns the category and the matched suffix of a file name
+func downloadCategory(name string) (string, string) {
+	lower := strings.ToLower(name)
+	var best string
+	for suffix := range downloadCategories {
+		if strings.HasSuffix(lower, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	return downloadCategories[best], best
+}
+
+// extractedDir returns the folder the archive was extracted to, if it sits
+// next to it: either named like the archive, or like the top level dir of
+// the first entry of a zip or tar.gz
+func extractedDir(path, suffix string) string {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+	candidates := []string{base[:len(base)-len(suffix)]}
+	if top := archiveTopDir(path, suffix); top != "" {
+		candidates = append(candidates, top)
+	}
+	for _, name := range candidates {
+		if p := filepath.Join(dir, name); name != "" && dirExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// archiveTopDir returns the first path component of the first entry of a
+// zip or gzip compressed tar archive
+func archiveTopDir(path, suffix string) string {
+	var first string
+	switch suffix {
+	case ".zip":
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return ""
+		}
+		defer r.Close()
+		if len(r.File) > 0 {
+			first = r.File[0].Name
+		}
+	case ".tar.gz", ".tgz":
+		f, err := os.Open(path)
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return ""
+		}
+		defer gz.Close()
+		if hdr, err := tar.NewReader(gz).Next(); err == nil {
+			first = hdr.Name
+		}
+	}
+	top, _, _ := strings.Cut(strings.TrimPrefix(first, "./"), "/")
+	return top
+}
+
+// packageInstalled reports whether the package in a .deb file is installed
+// in the same or a newer version, or the one in an .rpm file in that version
+func packageInstalled(path, suffix string) bool {
+	switch suffix {
+	case ".deb":
+		if _, err := exec.LookPath("dpkg-deb"); err != nil {
+			return false
+		}
+		out, err := exec.Command("dpkg-deb", "--showformat=${Package} ${Version}", "--show", path).Output()
+		name, version, ok := strings.Cut(strings.TrimSpace(string(out)), " ")
+		if err != nil || !ok {
+			return false
+		}
+		out, err = exec.Command("dpkg-query", "--showformat=${Status} ${Version}", "--show", name).Output()
+		if err != nil {
+			return false
+		}
+		installed, ok := strings.CutPrefix(string(out), "install ok installed ")
+		return ok && exec.Command("dpkg", "--compare-versions", installed, "ge", version).Run() == nil
+	case ".rpm":
+		if _, err := exec.LookPath("rpm"); err != nil {
+			return false
+		}
+		out, err := exec.Command("rpm", "-qp", "--qf", "%{NAME}-%{VERSION}-%{RELEASE}", path).Output()
+		if err != nil || len(out) == 0 {
+			return false
+		}
+		// rpm -q fails unless exactly this version is installed
+		return exec.Command("rpm", "-q", string(out)).Run() == nil
+	}
+	return false
+}
+
+func (c *DownloadsCleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.Remove(t.Path)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadsExtractedArchive(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		category string
+		suffix   string
+	}{
+		{"tool-1.2.tar.gz", "archive", ".tar.gz"},
+		{"Ubuntu.ISO", "disk image", ".iso"},
+		{"app_1.0_amd64.deb", "package", ".deb"},
+		{"notes.txt", "", ""},
+	}
+	for _, tt := range tests {
+		if cat, suffix := downloadCategory(tt.name); cat != tt.category || suffix != tt.suffix {
+			t.Errorf("downloadCategory(%q) = %q, %q; want %q, %q", tt.name, cat, suffix, tt.category, tt.suffix)
+		}
+	}
+
+	// The zip's content lives in "project-main", not "project"
+	archive := filepath.Join(dir, "project.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	if _, err := w.Create("project-main/README"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	f.Close()
+
+	if got := extractedDir(archive, ".zip"); got != "" {
+		t.Errorf("extractedDir before extraction = %q", got)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "project-main"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := extractedDir(archive, ".zip"); got != filepath.Join(dir, "project-main") {
+		t.Errorf("extractedDir after extraction = %q", got)
+	}
+}
@@ -0,0 +1,358 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultDuplicateMinSize skips small files, which free little and are
+// often duplicated on purpose (licenses, icons, configs)
+const defaultDuplicateMinSize = 1024 * 1024
+
+// duplicatePartialSize is how much of each file the partial hash covers
+const duplicatePartialSize = 64 * 1024
+
+// ficlone is the FICLONE ioctl, _IOW(0x94, 9, int)
+const ficlone = 0x40049409
+
+// dupFile is a duplicate candidate found by the walk
+type dupFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// DuplicateCleaner finds files with identical content below Roots. Candidates
+// are grouped by size, then by a hash of their first 64 KiB and finally by a
+// hash of the whole file, hashing on a pool of Workers. Redundant copies are
+// deleted or, depending on the mode, replaced by hard links or reflinks.
+// Nothing is touched unless the copies to keep were picked with SetKeep or
+// KeepOldest is set.
+type DuplicateCleaner struct {
+	// Roots are the dirs to search, the home directory when empty
+	Roots []string
+	// MinSize is the smallest file considered
+	MinSize int64
+	// Workers is the number of files hashed in parallel
+	Workers int
+	// DedupMode is what happens to the redundant copies
+	DedupMode DedupMode
+	// KeepOldest keeps the oldest copy of every group when SetKeep was not
+	// called, as in CLI mode
+	KeepOldest bool
+
+	groups []DuplicateGroup
+	files  map[string]dupFile
+	keep   map[string]string
+}
+
+func (c *DuplicateCleaner) Name() string {
+	return "Duplicate Files"
+}
+
+func (c *DuplicateCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *DuplicateCleaner) Groups() []DuplicateGroup {
+	return c.groups
+}
+
+func (c *DuplicateCleaner) SetKeep(keep map[string]string) {
+	c.keep = keep
+}
+
+func (c *DuplicateCleaner) Mode() DedupMode {
+	return c.DedupMode
+}
+
+func (c *DuplicateCleaner) SetMode(mode DedupMode) {
+	c.DedupMode = mode
+}
+
+func (c *DuplicateCleaner) Scan() (int64, error) {
+	roots := c.Roots
+	if len(roots) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return 0, err
+		}
+		roots = []string{home}
+	}
+	minSize := c.MinSize
+	if minSize <= 0 {
+		minSize = defaultDuplicateMinSize
+	}
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	c.groups = nil
+	c.keep = nil
+	c.files = make(map[string]dupFile)
+
+	// Stage 1: group by size. Hard links of one inode are a single file.
+	bySize := make(map[int64][]string)
+	seen := make(map[[2]uint64]bool)
+	for _, root := range roots {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Size() < minSize {
+				return nil
+			}
+			if st, ok := info.Sys().(*syscall.Stat_t); ok {
+				id := [2]uint64{uint64(st.Dev), st.Ino}
+				if seen[id] {
+					return nil
+				}
+				seen[id] = true
+			}
+			c.files[path] = dupFile{path: path, size: info.Size(), modTime: info.ModTime()}
+			bySize[info.Size()] = append(bySize[info.Size()], path)
+			return nil
+		})
+	}
+
+	var candidates [][]string
+	for _, paths := range bySize {
+		if len(paths) > 1 {
+			candidates = append(candidates, paths)
+		}
+	}
+
+	// Stage 2 and 3: partial, then full hash
+	candidates, _ = regroupByHash(candidates, duplicatePartialSize, workers)
+	candidates, hashes := regroupByHash(candidates, 0, workers)
+
+	var size int64
+	for gi, paths := range candidates {
+		sort.Slice(paths, func(i, j int) bool {
+			a, b := c.files[paths[i]], c.files[paths[j]]
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.Before(b.modTime)
+			}
+			return paths[i] < paths[j]
+		})
+		g := DuplicateGroup{
+			ID:    hashes[gi],
+			Size:  c.files[paths[0]].size,
+			Files: paths,
+			Keep:  paths[0],
+		}
+		c.groups = append(c.groups, g)
+		size += g.Size * int64(len(paths)-1)
+	}
+
+	// Biggest savings first
+	sort.Slice(c.groups, func(i, j int) bool {
+		a, b := c.groups[i], c.groups[j]
+		return a.Size*int64(len(a.Files)-1) > b.Size*int64(len(b.Files)-1)
+	})
+	return size, nil
+}
+
+// regroupByHash splits every group by the hash of the first limit bytes of
+// its files (all of them when limit is 0) and drops groups left with one
+// file. It returns the new groups and the hash each one shares.
+func regroupByHash(groups [][]string, limit int64, workers int) ([][]string, []string) {
+	var paths []string
+	for _, g := range groups {
+		paths = append(paths, g...)
+	}
+	hashes := hashFiles(paths, limit, workers)
+
+	var out [][]string
+	var outHashes []string
+	for _, g := range groups {
+		byHash := make(map[string][]string)
+		for _, p := range g {
+			if h, ok := hashes[p]; ok {
+				byHash[h] = append(byHash[h], p)
+			}
+		}
+		for _, h := range sortedKeys(byHash) {
+			if len(byHash[h]) > 1 {
+				out = append(out, byHash[h])
+				outHashes = append(outHashes, h)
+			}
+		}
+	}
+	return out, outHashes
+}
+
+// hashFiles hashes paths on a pool of workers. Unreadable files are left out.
+func hashFiles(paths []string, limit int64, workers int) map[string]string {
+	type result struct {
+		path, hash string
+	}
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				if h, err := hashFile(p, limit); err == nil {
+					results <- result{p, h}
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, p := range paths {
+			jobs <- p
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	hashes := make(map[string]string, len(paths))
+	for r := range results {
+		hashes[r.path] = r.hash
+	}
+	return hashes
+}
+
+// hashFile returns the hex SHA-256 of the first limit bytes of path, or of
+// the whole file when limit is 0
+func hashFile(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit > 0 {
+		r = io.LimitReader(f, limit)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *DuplicateCleaner) Clean() error {
+	if c.keep == nil && !c.KeepOldest {
+		return nil
+	}
+
+	var errs []error
+	for _, g := range c.groups {
+		keep := g.Keep
+		if c.keep != nil {
+			var ok bool
+			if keep, ok = c.keep[g.ID]; !ok {
+				continue
+			}
+		}
+
+		// Nothing is touched when the kept copy changed since the scan
+		if !c.unchanged(keep) {
+			continue
+		}
+		for _, p := range g.Files {
+			if p == keep || !c.unchanged(p) {
+				continue
+			}
+
+			var err error
+			switch c.DedupMode {
+			case DedupHardlink:
+				err = replaceFile(p, func(tmp string) error { return os.Link(keep, tmp) })
+			case DedupReflink:
+				err = replaceFile(p, func(tmp string) error { return reflinkFile(keep, tmp) })
+			default:
+				err = os.Remove(p)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unchanged reports whether path still has the size and mtime seen by Scan
+func (c *DuplicateCleaner) unchanged(path string) bool {
+	f, ok := c.files[path]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == f.size && info.ModTime().Equal(f.modTime)
+}
+
+// replaceFile atomically replaces path with a file created by create at a
+// unique temporary name in the same dir
+func replaceFile(path string, create func(tmp string) error) error {
+	// Reserve the name; links and clones need it not to exist
+	f, err := os.CreateTemp(filepath.Dir(path), ".goclean-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := os.Remove(tmp); err != nil {
+		return err
+	}
+
+	if err := create(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// reflinkFile creates dst as a copy-on-write clone of src. It fails on file
+// systems without reflink support instead of falling back to a full copy.
+func reflinkFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd()); errno != 0 {
+		return fmt.Errorf("reflink: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestDuplicateCleaner(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.iso":           "same content",
+		"Downloads/a.iso": "same content",
+		"other.iso":       "diff content",
+		".hidden/a.iso":   "same content",
+	})
+	a, b := filepath.Join(dir, "a.iso"), filepath.Join(dir, "Downloads", "a.iso")
+
+	c := &DuplicateCleaner{Roots: []string{dir}, MinSize: 1, DedupMode: DedupHardlink}
+	size, err := c.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := c.Groups()
+	if len(groups) != 1 || len(groups[0].Files) != 2 || size != int64(len("same content")) {
+		t.Fatalf("Scan() = %d, groups %+v", size, groups)
+	}
+
+	// Without picked copies, as in CLI mode, nothing is touched
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if ai, _ := os.Stat(a); ai == nil || ai.Sys().(*syscall.Stat_t).Nlink != 1 {
+		t.Fatalf("%s was touched without SetKeep", a)
+	}
+
+	// A temp file left behind by an earlier run is no obstacle
+	writeTestFiles(t, dir, map[string]string{".goclean-a.iso": "leftover"})
+	c.SetKeep(map[string]string{groups[0].ID: b})
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	ai, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(ai, bi) {
+		t.Errorf("%s was not replaced by a hard link to %s", a, b)
+	}
+}
@@ -6,6 +6,42 @@ import (
 	"strings"
 )
 
+// dynamicCacheExcludes lists ~/.cache entries that have a dedicated cleaner
+var dynamicCacheExcludes = map[string]bool{
+	"thumbnails":     true,
+	"google-chrome":  true,
+	"chromium":       true,
+	"mozilla":        true,
+	"BraveSoftware":  true,
+	"microsoft-edge": true,
+	"vivaldi":        true,
+	"opera":          true,
+	"librewolf":      true,
+	"waterfox":       true,
+	"yarn":           true,
+	"composer":       true,
+	"ccache":         true,
+	"sccache":        true,
+	"bazel":          true,
+	"huggingface":    true,
+	"torch":          true,
+	"ms-playwright":  true,
+	"puppeteer":      true,
+	"Cypress":        true,
+	"JetBrains":      true,
+
+	// Browser channels, also handled by BrowserCleaner
+	"google-chrome-beta":     true,
+	"google-chrome-unstable": true,
+	"microsoft-edge-beta":    true,
+
+	// GPU shader caches, listed on their own by SteamCleaner
+	"mesa_shader_cache":    true,
+	"mesa_shader_cache_db": true,
+	"radv_builtin_shaders": true,
+	"nvidia":               true,
+}
+
 type DynamicCacheCleaner struct{}
 
 func (c *DynamicCacheCleaner) Name() string {
@@ -25,15 +61,6 @@ func (c *DynamicCacheCleaner) Scan() (int64, error) {
 	cacheDir := filepath.Join(home, ".cache")
 	var size int64
 	
-	// Exclude what we already handle
-	excludes := map[string]bool{
-		"thumbnails":    true,
-		"google-chrome": true,
-		"chromium":      true,
-		"mozilla":       true,
-		"BraveSoftware": true,
-	}
-
 	err = filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
@@ -49,7 +76,7 @@ func (c *DynamicCacheCleaner) Scan() (int64, error) {
 		rel, _ := filepath.Rel(cacheDir, path)
 		parts := strings.Split(rel, string(os.PathSeparator))
 		if len(parts) > 0 {
-			if excludes[parts[0]] {
+			if dynamicCacheExcludes[parts[0]] {
 				if info.IsDir() {
 					return filepath.SkipDir
 				}
@@ -72,14 +99,6 @@ func (c *DynamicCacheCleaner) Clean() error {
 		return err
 	}
 	cacheDir := filepath.Join(home, ".cache")
-	
-	excludes := map[string]bool{
-		"thumbnails":    true,
-		"google-chrome": true,
-		"chromium":      true,
-		"mozilla":       true,
-		"BraveSoftware": true,
-	}
 
 	// Walk top level directories in ~/.cache and remove them if not excluded
 	entries, err := os.ReadDir(cacheDir)
@@ -88,7 +107,7 @@ func (c *DynamicCacheCleaner) Clean() error {
 	}
 
 	for _, entry := range entries {
-		if !excludes[entry.Name()] {
+		if !dynamicCacheExcludes[entry.Name()] {
 			path := filepath.Join(cacheDir, entry.Name())
 			if err := os.RemoveAll(path); err != nil {
 				// Log error?
@@ -0,0 +1,244 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// emptySkipDirs are never descended into: their empty dirs and links are
+// part of how the owning tool works. Hidden dirs below a root are skipped
+// as well.
+var emptySkipDirs = map[string]bool{
+	"Trash": true,
+}
+
+// emptyLockLinks are symlinks that dangle on purpose: browsers point them at
+// "host-pid" to lock a profile to one running instance
+var emptyLockLinks = map[string]bool{
+	"SingletonLock":   true,
+	"SingletonSocket": true,
+	"SingletonCookie": true,
+	"lock":            true,
+	".parentlock":     true,
+}
+
+// emptyNoPreselect, relative to home, hold app state whose empty dirs and
+// links the apps may expect; what is found below them is never preselected
+var emptyNoPreselect = []string{".config", ".local", ".mozilla"}
+
+// emptyKeepDirs, relative to home, are kept even when empty
+var emptyKeepDirs = []string{
+	".cache",
+	".config",
+	".local",
+	".local/bin",
+	".local/share",
+	".local/state",
+	".config/autostart",
+}
+
+// EmptyDirCleaner finds chains of empty directories, bottom-up, and symlinks
+// whose target is gone below Roots. XDG user dirs, well known dot dirs and
+// hidden dirs are left alone, and so are the lock links browsers keep in
+// their profiles. Entries directly below a root or in app state dirs are
+// listed but not preselected.
+type EmptyDirCleaner struct {
+	detailSet
+
+	// Roots are the dirs to search, the home directory when empty
+	Roots []string
+
+	// chains maps each reported dir to the empty dirs below it, deepest first
+	chains map[string][]string
+}
+
+func (c *EmptyDirCleaner) Name() string {
+	return "Empty Dirs & Broken Symlinks"
+}
+
+func (c *EmptyDirCleaner) RequiresRoot() bool {
+	return false
+}
+
+// emptyScan holds what one Scan needs while recursing
+type emptyScan struct {
+	c        *EmptyDirCleaner
+	keep     map[string]bool
+	noSelect []string
+	procs    *ProcessSnapshot
+	dev      uint64
+}
+
+func (c *EmptyDirCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+	roots := c.Roots
+	if len(roots) == 0 {
+		roots = []string{home}
+	}
+
+	keep := map[string]bool{home: true}
+	for _, d := range emptyKeepDirs {
+		keep[filepath.Join(home, d)] = true
+	}
+	for _, d := range xdgUserDirs(home) {
+		keep[d] = true
+	}
+	for _, r := range roots {
+		keep[filepath.Clean(r)] = true
+	}
+
+	c.found = nil
+	c.chains = make(map[string][]string)
+	s := &emptyScan{c: c, keep: keep, procs: SnapshotProcesses()}
+	for _, d := range emptyNoPreselect {
+		s.noSelect = append(s.noSelect, filepath.Join(home, d))
+	}
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			continue
+		}
+		// Mounted file systems below the root are not ours to tidy up
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			s.dev = uint64(st.Dev)
+		}
+		s.visit(filepath.Clean(root), 0)
+	}
+	return totalSize(c.found), nil
+}
+
+// visit reports broken links and empty dir chains below dir. It returns
+// whether dir itself is removable once the chains below it are, together
+// with those dirs, deepest first.
+func (s *emptyScan) visit(dir string, depth int) (bool, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, nil
+	}
+
+	empty := true
+	var chains [][]string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		switch {
+		case e.Type()&os.ModeSymlink != 0:
+			empty = false
+			if emptyLockLinks[e.Name()] {
+				continue
+			}
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				s.addBrokenLink(path, depth+1)
+			}
+		case e.IsDir():
+			if emptySkipDirs[e.Name()] || strings.HasPrefix(e.Name(), ".") || !s.sameDevice(e) {
+				empty = false
+				continue
+			}
+			childEmpty, chain := s.visit(path, depth+1)
+			if childEmpty {
+				chains = append(chains, chain)
+			} else {
+				empty = false
+			}
+		default:
+			empty = false
+		}
+	}
+
+	if empty && !s.keep[dir] && !s.procs.InUse(dir) {
+		var all []string
+		for _, ch := range chains {
+			all = append(all, ch...)
+		}
+		return true, append(all, dir)
+	}
+
+	// dir stays, so each empty chain below it is an entry of its own
+	for _, ch := range chains {
+		s.addChain(ch, depth+1)
+	}
+	return false, nil
+}
+
+// preselect reports whether an entry at depth below its root, found at
+// path, may be preselected
+func (s *emptyScan) preselect(path string, depth int) bool {
+	if depth <= 1 {
+		return false
+	}
+	for _, d := range s.noSelect {
+		if path == d || strings.HasPrefix(path, d+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDevice reports whether the dir entry is on the root's file system
+func (s *emptyScan) sameDevice(e os.DirEntry) bool {
+	info, err := e.Info()
+	if err != nil {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || uint64(st.Dev) == s.dev
+}
+
+func (s *emptyScan) addBrokenLink(path string, depth int) {
+	info, err := os.Lstat(path)
+	if err != nil || s.procs.InUse(path) {
+		return
+	}
+	target, _ := os.Readlink(path)
+	s.c.found = append(s.c.found, FileDetail{
+		Path:     path,
+		Size:     info.Size(),
+		Label:    path + " -> " + target + " (broken)",
+		Selected: s.preselect(path, depth),
+	})
+}
+
+func (s *emptyScan) addChain(chain []string, depth int) {
+	top := chain[len(chain)-1]
+	var size int64
+	for _, d := range chain {
+		if info, err := os.Lstat(d); err == nil {
+			size += info.Size()
+		}
+	}
+	label := top + "/"
+	if len(chain) > 1 {
+		label += " (" + strconv.Itoa(len(chain)) + " empty dirs)"
+	}
+	s.c.chains[top] = chain
+	s.c.found = append(s.c.found, FileDetail{
+		Path:     top,
+		Size:     size,
+		Label:    label,
+		Selected: s.preselect(top, depth),
+	})
+}
+
+func (c *EmptyDirCleaner) Clean() error {
+	for _, t := range c.targets() {
+		chain, isDir := c.chains[t.Path]
+		if !isDir {
+			// Only remove the link if it is still dangling
+			if _, err := os.Stat(t.Path); os.IsNotExist(err) {
+				_ = os.Remove(t.Path)
+			}
+			continue
+		}
+		// os.Remove refuses dirs that got content since the scan
+		for _, d := range chain {
+			_ = os.Remove(d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTestDirs creates empty dirs below root
+func makeTestDirs(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// makeTestLinks creates symlinks below root, mapped to their targets
+func makeTestLinks(t *testing.T, root string, links map[string]string) {
+	t.Helper()
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmptyDirScan(t *testing.T) {
+	runScanCases(t, func() DetailCleaner { return &EmptyDirCleaner{} }, []scanCase{
+		{
+			name:  "chains and dangling links",
+			files: map[string]string{"keep/file": ""},
+			setup: func(t *testing.T, home string) {
+				makeTestDirs(t, home, "a/b/c", "a/d", "keep/x", "proj/.git/refs/tags", "top")
+				makeTestLinks(t, home, map[string]string{"keep/dangling": filepath.Join(home, "missing")})
+			},
+			// Entries directly below a root are not preselected
+			want: map[string]bool{
+				"a":             false,
+				"top":           false,
+				"keep/x":        true,
+				"keep/dangling": true,
+			},
+		},
+		{
+			name:  "XDG user dirs",
+			files: map[string]string{".config/user-dirs.dirs": "XDG_DOWNLOAD_DIR=\"$HOME/Dl\"\n"},
+			setup: func(t *testing.T, home string) {
+				makeTestDirs(t, home, "Dl", "Desktop")
+			},
+			want: map[string]bool{"Desktop": false},
+		},
+	})
+
+	// Hidden dirs below a root are skipped, a hidden root is not, and lock
+	// links and app state are left alone
+	runScanCases(t, func() DetailCleaner {
+		home := os.Getenv("HOME")
+		return &EmptyDirCleaner{Roots: []string{filepath.Join(home, "apps"), filepath.Join(home, ".config")}}
+	}, []scanCase{{
+		name:  "lock links and app state",
+		files: map[string]string{"apps/chromium/Default/Preferences": ""},
+		setup: func(t *testing.T, home string) {
+			makeTestDirs(t, home, ".config/systemd/user", ".hidden/x/y")
+			makeTestLinks(t, home, map[string]string{
+				"apps/chromium/SingletonLock":   "myhost-4242",
+				"apps/chromium/SingletonSocket": "myhost-4242",
+				".config/systemd/gone":          "myhost-4242",
+			})
+		},
+		want: map[string]bool{
+			".config/systemd/gone": false,
+			".config/systemd/user": false,
+		},
+	}})
+}
+
+func TestEmptyDirClean(t *testing.T) {
+	root := t.TempDir()
+	makeTestDirs(t, root, "a/b/c", "a/d", "keep/x")
+	writeTestFiles(t, root, map[string]string{"keep/file": ""})
+	makeTestLinks(t, root, map[string]string{"keep/dangling": filepath.Join(root, "missing")})
+
+	c := &EmptyDirCleaner{Roots: []string{root}}
+	if _, err := c.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	c.SetFilesToClean([]string{filepath.Join(root, "a"), filepath.Join(root, "keep", "dangling")})
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a", "keep/dangling"} {
+		if _, err := os.Lstat(filepath.Join(root, p)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", p)
+		}
+	}
+	if !dirExists(filepath.Join(root, "keep", "x")) {
+		t.Error("keep/x was removed without being picked")
+	}
+}
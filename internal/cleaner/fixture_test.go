@@ -0,0 +1,96 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles creates files below root, parent dirs included, from a map
+// of slash separated relative paths to contents
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// scanCase is a fixture home dir and what a cleaner's Scan should find in it
+type scanCase struct {
+	name string
+	// files are written below a fresh HOME
+	files map[string]string
+	// env is set as well; "$HOME" in values is replaced by the fixture HOME
+	env map[string]string
+	// setup, if set, runs after the files are written
+	setup func(t *testing.T, home string)
+	// want maps every entry Scan should report, relative to HOME, to
+	// whether it is preselected
+	want map[string]bool
+	// labels optionally gives the expected labels of some entries
+	labels map[string]string
+	// size, if set, is what Scan should return
+	size int64
+}
+
+// runScanCases scans the fixture of each case with a new cleaner and checks
+// the entries found, their preselection and labels
+func runScanCases(t *testing.T, newCleaner func() DetailCleaner, cases []scanCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			for k, v := range tc.env {
+				t.Setenv(k, strings.ReplaceAll(v, "$HOME", home))
+			}
+			writeTestFiles(t, home, tc.files)
+			if tc.setup != nil {
+				tc.setup(t, home)
+			}
+
+			c := newCleaner()
+			size, err := c.Scan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.size != 0 && size != tc.size {
+				t.Errorf("Scan() = %d, want %d", size, tc.size)
+			}
+			found := make(map[string]FileDetail)
+			for _, d := range c.Details() {
+				rel, err := filepath.Rel(home, d.Path)
+				if err != nil {
+					rel = d.Path
+				}
+				found[filepath.ToSlash(rel)] = d
+			}
+			for rel, d := range found {
+				selected, ok := tc.want[rel]
+				switch {
+				case !ok:
+					t.Errorf("unexpected entry %s (%s)", rel, d.Label)
+				case d.Selected != selected:
+					t.Errorf("%s (%s) selected = %v, want %v", rel, d.Label, d.Selected, selected)
+				}
+			}
+			for rel := range tc.want {
+				if _, ok := found[rel]; !ok {
+					t.Errorf("missing entry %s", rel)
+				}
+			}
+			for rel, label := range tc.labels {
+				if d, ok := found[rel]; ok && d.Label != label {
+					t.Errorf("%s label = %q, want %q", rel, d.Label, label)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package cleaner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultGitConcurrency is how many repos are collected at once when
+// Concurrency is not set
+const defaultGitConcurrency = 4
+
+// gitMinReclaimable is the estimate below which a repo is not preselected
+const gitMinReclaimable = 1024 * 1024
+
+// gitBusyMarkers are files in the git dir that mean an operation is in
+// progress; collecting garbage then could lose the user's state
+var gitBusyMarkers = []struct {
+	name   string
+	reason string
+}{
+	{"rebase-merge", "rebase in progress"},
+	{"rebase-apply", "rebase in progress"},
+	{"MERGE_HEAD", "merge in progress"},
+	{"CHERRY_PICK_HEAD", "cherry-pick in progress"},
+	{"REVERT_HEAD", "revert in progress"},
+	{"BISECT_LOG", "bisect in progress"},
+	{"index.lock", "git running"},
+	{"gc.pid", "gc running"},
+}
+
+// GitRepoCleaner discovers git repositories below Roots, estimates what
+// `git gc` would reclaim from `git count-objects -v` (loose objects and
+// garbage) and collects the selected ones, Concurrency at a time: reflog
+// entries for unreachable commits are expired first with `git reflog expire
+// --expire-unreachable=now --all`, since they would otherwise keep those
+// objects alive for gc.reflogExpireUnreachable (30 days), then `git gc
+// --prune` runs. Reachable reflog history is left alone. Repos in the middle
+// of a rebase, merge or similar are never touched.
+type GitRepoCleaner struct {
+	detailSet
+
+	// Roots are the dirs to search, the home directory when empty
+	Roots []string
+	// Concurrency is how many repos are collected at once
+	Concurrency int
+
+	mu      sync.Mutex
+	done    int
+	total   int
+	running map[string]bool
+	gitDirs map[string]string
+}
+
+func (c *GitRepoCleaner) Name() string {
+	return "Git Repositories (gc)"
+}
+
+func (c *GitRepoCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *GitRepoCleaner) Scan() (int64, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return 0, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+	roots := c.Roots
+	if len(roots) == 0 {
+		roots = []string{home}
+	}
+
+	c.found = nil
+	c.gitDirs = make(map[string]string)
+	for _, root := range roots {
+		for repo, gitDir := range findGitRepos(root) {
+			c.gitDirs[repo] = gitDir
+		}
+	}
+
+	for _, repo := range sortedKeys(c.gitDirs) {
+		stats := gitCountObjects(repo)
+		reclaimable := (stats["size"] + stats["size-garbage"]) * 1024
+		if reclaimable == 0 {
+			continue
+		}
+
+		label := repo
+		if rel, err := filepath.Rel(home, repo); err == nil && !strings.HasPrefix(rel, "..") {
+			label = "~/" + rel
+		}
+		label += fmt.Sprintf(" (%d loose objects, %s garbage)", stats["count"], formatSize(stats["size-garbage"]*1024))
+
+		detail := FileDetail{
+			Path:     repo,
+			Size:     reclaimable,
+			Label:    label,
+			Selected: reclaimable >= gitMinReclaimable,
+		}
+		if reason := gitBusy(c.gitDirs[repo]); reason != "" {
+			detail.Warning = reason
+			detail.Selected = false
+		}
+		c.found = append(c.found, detail)
+	}
+
+	sort.SliceStable(c.found, func(i, j int) bool { return c.found[i].Size > c.found[j].Size })
+	return totalSize(c.found), nil
+}
+
+// findGitRepos maps the work trees (or bare repos) below root to their git
+// dirs. Hidden dirs and node_modules are not searched, nor are repos
+// searched for nested ones.
+func findGitRepos(root string) map[string]string {
+	repos := make(map[string]string)
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+
+		if info, err := os.Stat(filepath.Join(path, ".git")); err == nil && info.IsDir() {
+			repos[path] = filepath.Join(path, ".git")
+			return filepath.SkipDir
+		}
+		// Bare clones such as "project.git"
+		if strings.HasSuffix(d.Name(), ".git") && dirExists(filepath.Join(path, "objects")) {
+			if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+				repos[path] = path
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	return repos
+}
+
+// gitCountObjects parses `git count-objects -v`; sizes are in KiB
+func gitCountObjects(repo string) map[string]int64 {
+	stats := make(map[string]int64)
+	out, err := exec.Command("git", "-C", repo, "count-objects", "-v").Output()
+	if err != nil {
+		return stats
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			stats[key] = n
+		}
+	}
+	return stats
+}
+
+// gitBusy returns why the repo must not be touched right now, if it must not
+func gitBusy(gitDir string) string {
+	for _, m := range gitBusyMarkers {
+		if _, err := os.Stat(filepath.Join(gitDir, m.name)); err == nil {
+			return m.reason
+		}
+	}
+	return ""
+}
+
+func (c *GitRepoCleaner) Progress() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.total == 0 {
+		return ""
+	}
+
+	var names []string
+	for _, repo := range sortedKeys(c.running) {
+		names = append(names, filepath.Base(repo))
+	}
+	status := fmt.Sprintf("%d/%d repos", c.done, c.total)
+	if len(names) > 0 {
+		status += ", gc " + strings.Join(names, ", ")
+	}
+	return status
+}
+
+func (c *GitRepoCleaner) Clean() error {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultGitConcurrency
+	}
+	targets := c.targets()
+
+	c.mu.Lock()
+	c.done, c.total = 0, len(targets)
+	c.running = make(map[string]bool)
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var failed []string
+	sem := make(chan struct{}, concurrency)
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(repo string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.setRunning(repo, true)
+			defer c.setRunning(repo, false)
+
+			// Re-check: the user may have started a rebase since the scan
+			if gitBusy(c.gitDirs[repo]) != "" {
+				return
+			}
+			if err := gitCollect(repo); err != nil {
+				errMu.Lock()
+				failed = append(failed, filepath.Base(repo))
+				errMu.Unlock()
+			}
+		}(t.Path)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("git gc failed in %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// gitCollect expires the unreachable reflog entries of repo and runs gc
+func gitCollect(repo string) error {
+	if err := exec.Command("git", "-C", repo, "reflog", "expire", "--expire-unreachable=now", "--all").Run(); err != nil {
+		return err
+	}
+	return exec.Command("git", "-C", repo, "gc", "--quiet", "--prune").Run()
+}
+
+func (c *GitRepoCleaner) setRunning(repo string, running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if running {
+		c.running[repo] = true
+		return
+	}
+	delete(c.running, repo)
+	c.done++
+}
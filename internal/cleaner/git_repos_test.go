@@ -0,0 +1,83 @@
+package cleaner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindGitRepos(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{
+		"src/app/.git/objects",
+		"src/app/vendor/lib/.git/objects",
+		"src/busy/.git/rebase-merge",
+		"mirror.git/objects",
+		".hidden/repo/.git/objects",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "mirror.git", "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := findGitRepos(root)
+	want := map[string]string{
+		filepath.Join(root, "src/app"):    filepath.Join(root, "src/app/.git"),
+		filepath.Join(root, "src/busy"):   filepath.Join(root, "src/busy/.git"),
+		filepath.Join(root, "mirror.git"): filepath.Join(root, "mirror.git"),
+	}
+	if len(repos) != len(want) {
+		t.Fatalf("findGitRepos() = %v, want %v", repos, want)
+	}
+	for repo, gitDir := range want {
+		if repos[repo] != gitDir {
+			t.Errorf("findGitRepos()[%s] = %q, want %q", repo, repos[repo], gitDir)
+		}
+	}
+
+	if got := gitBusy(want[filepath.Join(root, "src/busy")]); got != "rebase in progress" {
+		t.Errorf("gitBusy(busy) = %q", got)
+	}
+	if got := gitBusy(want[filepath.Join(root, "src/app")]); got != "" {
+		t.Errorf("gitBusy(app) = %q", got)
+	}
+}
+
+func TestGitCollectExpiresUnreachableReflog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "test")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	old := git("rev-parse", "HEAD")
+	// Amending leaves the first commit reachable from the reflog only
+	git("commit", "--quiet", "--allow-empty", "--amend", "-m", "amended")
+
+	if err := gitCollect(repo); err != nil {
+		t.Fatal(err)
+	}
+	if log := git("reflog", "--all", "--format=%H"); strings.Contains(log, old) {
+		t.Errorf("reflog still holds unreachable %s:\n%s", old, log)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git", "packed-refs")); err != nil {
+		t.Errorf("gc did not run: %v", err)
+	}
+}
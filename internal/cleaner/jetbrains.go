@@ -0,0 +1,108 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// jetbrainsDirRe matches per-version dirs such as "IntelliJIdea2023.1" or "PyCharmCE2024.2"
+var jetbrainsDirRe = regexp.MustCompile(`^([A-Za-z]+)(\d{4}\.\d+)$`)
+
+// jetbrainsLocation is one of the base dirs JetBrains IDEs keep per-version data in
+type jetbrainsLocation struct {
+	base string
+	kind string
+	// preselect is false for data the user most likely wants to keep
+	preselect bool
+}
+
+// JetBrainsCleaner groups JetBrains IDE dirs by product and version and
+// offers the data of every version older than the newest one per product.
+// Config dirs are listed but left unselected.
+type JetBrainsCleaner struct {
+	detailSet
+}
+
+func (c *JetBrainsCleaner) Name() string {
+	return "JetBrains Old IDE Versions"
+}
+
+func (c *JetBrainsCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *JetBrainsCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	locations := []jetbrainsLocation{
+		{filepath.Join(home, ".cache", "JetBrains"), "caches & logs", true},
+		{filepath.Join(home, ".local", "share", "JetBrains"), "plugins & data", true},
+		{filepath.Join(home, ".config", "JetBrains"), "config", false},
+	}
+
+	type versionDir struct {
+		version string
+		path    string
+		loc     jetbrainsLocation
+	}
+	products := make(map[string][]versionDir)
+	newest := make(map[string]string)
+
+	for _, loc := range locations {
+		entries, err := os.ReadDir(loc.base)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			m := jetbrainsDirRe.FindStringSubmatch(e.Name())
+			if !e.IsDir() || m == nil {
+				continue
+			}
+			product, version := m[1], m[2]
+			products[product] = append(products[product], versionDir{version, filepath.Join(loc.base, e.Name()), loc})
+			if compareVersions(version, newest[product]) > 0 {
+				newest[product] = version
+			}
+		}
+	}
+
+	names := make([]string, 0, len(products))
+	for p := range products {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+
+	c.found = nil
+	for _, product := range names {
+		dirs := products[product]
+		sort.Slice(dirs, func(i, j int) bool { return compareVersions(dirs[i].version, dirs[j].version) > 0 })
+		for _, d := range dirs {
+			if d.version == newest[product] {
+				continue
+			}
+			size, _ := simpleDirScan(d.path)
+			if size == 0 {
+				continue
+			}
+			c.found = append(c.found, FileDetail{
+				Path:     d.path,
+				Size:     size,
+				Label:    product + " " + d.version + " " + d.loc.kind + " (newest: " + newest[product] + ")",
+				Selected: d.loc.preselect,
+			})
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+func (c *JetBrainsCleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
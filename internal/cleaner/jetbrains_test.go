@@ -0,0 +1,29 @@
+package cleaner
+
+import "testing"
+
+func TestJetBrainsScan(t *testing.T) {
+	runScanCases(t, func() DetailCleaner { return &JetBrainsCleaner{} }, []scanCase{{
+		name: "old versions",
+		files: map[string]string{
+			".cache/JetBrains/IntelliJIdea2023.1/caches/index":     "idx",
+			".cache/JetBrains/IntelliJIdea2024.1/caches/index":     "idx",
+			".local/share/JetBrains/IntelliJIdea2023.1/plugin.jar": "jar",
+			".config/JetBrains/IntelliJIdea2023.1/options/a.xml":   "xml",
+			".config/JetBrains/GoLand2023.3/options/a.xml":         "xml",
+			".cache/JetBrains/GoLand2024.1/caches/index":           "idx",
+			".cache/JetBrains/PyCharmCE2024.2/caches/index":        "idx",
+			".config/JetBrains/consentOptions/accepted":            "yes",
+		},
+		want: map[string]bool{
+			".cache/JetBrains/IntelliJIdea2023.1":       true,
+			".local/share/JetBrains/IntelliJIdea2023.1": true,
+			".config/JetBrains/IntelliJIdea2023.1":      false,
+			".config/JetBrains/GoLand2023.3":            false,
+		},
+		labels: map[string]string{
+			".cache/JetBrains/IntelliJIdea2023.1": "IntelliJIdea 2023.1 caches & logs (newest: 2024.1)",
+			".config/JetBrains/GoLand2023.3":      "GoLand 2023.3 config (newest: 2024.1)",
+		},
+	}})
+}
@@ -0,0 +1,117 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// jsStore describes the package cache of one JavaScript package manager
+type jsStore struct {
+	label string
+	tool  string
+	path  string
+	// prune is the native cleanup command. Stores that have one are never
+	// deleted directly: the tool knows which parts are still linked.
+	prune []string
+}
+
+// JSCacheCleaner handles the npm, Yarn, pnpm and Bun package stores and
+// reports each store it finds as its own entry.
+type JSCacheCleaner struct {
+	detailSet
+}
+
+func (c *JSCacheCleaner) Name() string {
+	return "JavaScript Package Caches"
+}
+
+func (c *JSCacheCleaner) RequiresRoot() bool {
+	return false
+}
+
+func jsStores() ([]jsStore, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = filepath.Join(home, ".cache")
+	}
+
+	return []jsStore{
+		{"npm", "npm", filepath.Join(home, ".npm"), []string{"cache", "clean", "--force"}},
+		{"Yarn (classic)", "yarn", filepath.Join(cacheHome, "yarn"), []string{"cache", "clean"}},
+		// Berry's global mirror can only be cleaned from inside a project, so we delete it directly
+		{"Yarn (berry)", "yarn", filepath.Join(home, ".yarn", "berry", "cache"), nil},
+		// prune only drops packages no project links to anymore
+		{"pnpm store", "pnpm", filepath.Join(dataHome, "pnpm", "store"), []string{"store", "prune"}},
+		// `bun pm cache rm` deletes the whole dir as well
+		{"Bun", "bun", filepath.Join(home, ".bun", "install", "cache"), nil},
+	}, nil
+}
+
+func (c *JSCacheCleaner) Scan() (int64, error) {
+	stores, err := jsStores()
+	if err != nil {
+		return 0, err
+	}
+
+	c.found = nil
+	for _, s := range stores {
+		info, err := os.Stat(s.path)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		size, _ := simpleDirScan(s.path)
+		if size == 0 {
+			continue
+		}
+		c.found = append(c.found, FileDetail{
+			Path:     s.path,
+			Size:     size,
+			Label:    s.label + " (" + s.path + ")",
+			Selected: true,
+		})
+	}
+	return totalSize(c.found), nil
+}
+
+func (c *JSCacheCleaner) Clean() error {
+	stores, err := jsStores()
+	if err != nil {
+		return err
+	}
+
+	byPath := make(map[string]jsStore, len(stores))
+	for _, s := range stores {
+		byPath[s.path] = s
+	}
+
+	var errs []error
+	for _, t := range c.targets() {
+		s := byPath[t.Path]
+
+		// Let the package manager handle its own store layout
+		if s.prune != nil {
+			if out, err := exec.Command(s.tool, s.prune...).CombinedOutput(); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w: %s", s.tool, strings.Join(s.prune, " "), err, strings.TrimSpace(string(out))))
+			}
+			continue
+		}
+
+		if err := os.RemoveAll(t.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSCacheCleanPrunes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	writeTestFiles(t, home, map[string]string{
+		".local/share/pnpm/store/v3/files/00/abc": "package",
+		".bun/install/cache/left-pad@1.3.0/index": "package",
+	})
+
+	// pnpm is installed but its prune fails
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'store locked' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "pnpm"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	c := &JSCacheCleaner{}
+	if _, err := c.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.found) != 2 {
+		t.Fatalf("found %v, want the pnpm and Bun stores", c.found)
+	}
+	err := c.Clean()
+	if err == nil || !strings.Contains(err.Error(), "store locked") {
+		t.Errorf("Clean() = %v, want the pnpm error", err)
+	}
+	if !dirExists(filepath.Join(home, ".local/share/pnpm/store/v3/files/00")) {
+		t.Error("the pnpm store was deleted after its prune failed")
+	}
+	if dirExists(filepath.Join(home, ".bun/install/cache")) {
+		t.Error("the Bun cache, which has no prune, was kept")
+	}
+}
@@ -0,0 +1,361 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// minikubeISORe matches "minikube-v1.32.0-amd64.iso"
+	minikubeISORe = regexp.MustCompile(`^minikube-(v\d+\.\d+\.\d+[^-]*)`)
+	// minikubePreloadRe matches
+	// "preloaded-images-k8s-v18-v1.28.3-docker-overlay2-amd64.tar.lz4"
+	minikubePreloadRe = regexp.MustCompile(`^preloaded-images-k8s-v\d+-(v\d+\.\d+\.\d+[^-]*)-([a-z]+)-`)
+	// kubeVersionRe finds the Kubernetes version in a node image tag such as
+	// "v1.29.2" or "v1.27.4-k3s1"
+	kubeVersionRe = regexp.MustCompile(`^v\d+\.\d+\.\d+`)
+)
+
+// localKubeImages are the docker images local cluster tools run their nodes
+// and helpers from
+var localKubeImages = []struct {
+	repo string
+	tool string
+	node bool
+}{
+	{"kindest/node", "kind", true},
+	{"rancher/k3s", "k3d", true},
+	{"ghcr.io/k3d-io/k3d-proxy", "k3d", false},
+	{"ghcr.io/k3d-io/k3d-tools", "k3d", false},
+	{"gcr.io/k8s-minikube/kicbase", "minikube", false},
+}
+
+// localKubeClusterLabels are the container labels naming the cluster a
+// kind, k3d or minikube container belongs to
+var localKubeClusterLabels = []string{"io.x-k8s.kind.cluster", "k3d.cluster", "name.minikube.sigs.k8s.io"}
+
+// LocalKubeCleaner lists what local Kubernetes tools cache: minikube ISOs,
+// preloaded image tarballs, kicbase tarballs and binaries, and the kind, k3d
+// and minikube node images in docker, by Kubernetes version. Whatever no
+// existing minikube profile or cluster container uses is preselected.
+type LocalKubeCleaner struct {
+	detailSet
+
+	// images maps the entries that are docker images to the reference docker
+	// rmi removes them by: repo:tag, or the image ID for untagged ones
+	images map[string]string
+}
+
+func (c *LocalKubeCleaner) Name() string {
+	return "Local Kubernetes (minikube, kind, k3d)"
+}
+
+func (c *LocalKubeCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *LocalKubeCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	c.found = nil
+	c.images = make(map[string]string)
+	c.scanMinikube(minikubeHome(home))
+	c.scanNodeImages()
+	return totalSize(c.found), nil
+}
+
+// minikubeHome returns the .minikube dir; MINIKUBE_HOME may name it or its
+// parent
+func minikubeHome(home string) string {
+	dir := os.Getenv("MINIKUBE_HOME")
+	if dir == "" {
+		return filepath.Join(home, ".minikube")
+	}
+	if filepath.Base(dir) != ".minikube" {
+		dir = filepath.Join(dir, ".minikube")
+	}
+	return dir
+}
+
+// minikubeProfile is the part of profiles/<name>/config.json that says
+// which cached files the profile needs
+type minikubeProfile struct {
+	MinikubeISO      string
+	KicBaseImage     string
+	KubernetesConfig struct {
+		KubernetesVersion string
+		ContainerRuntime  string
+	}
+}
+
+// minikubeUsers maps what existing profiles use (ISO file names, kicbase
+// tags and Kubernetes versions) to the profile names using them
+func minikubeUsers(dir string) map[string][]string {
+	users := make(map[string][]string)
+	configs, _ := filepath.Glob(filepath.Join(dir, "profiles", "*", "config.json"))
+	for _, f := range configs {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var p minikubeProfile
+		if json.Unmarshal(data, &p) != nil {
+			continue
+		}
+		name := filepath.Base(filepath.Dir(f))
+		for _, key := range []string{
+			filepath.Base(p.MinikubeISO),
+			kicbaseTag(p.KicBaseImage),
+			p.KubernetesConfig.KubernetesVersion,
+		} {
+			if key != "" && key != "." {
+				users[key] = append(users[key], name)
+			}
+		}
+	}
+	return users
+}
+
+// kicbaseTag returns "v0.0.42" for "gcr.io/k8s-minikube/kicbase:v0.0.42@sha256:..."
+// and for the cached "kicbase_v0.0.42@sha256_....tar"
+func kicbaseTag(ref string) string {
+	ref, _, _ = strings.Cut(filepath.Base(ref), "@")
+	if i := strings.LastIndexAny(ref, ":_"); i >= 0 && strings.HasPrefix(ref, "kicbase") {
+		return ref[i+1:]
+	}
+	return ""
+}
+
+func (c *LocalKubeCleaner) scanMinikube(dir string) {
+	cache := filepath.Join(dir, "cache")
+	if !dirExists(cache) {
+		return
+	}
+	users := minikubeUsers(dir)
+
+	isos, _ := filepath.Glob(filepath.Join(cache, "iso", "*.iso"))
+	archIsos, _ := filepath.Glob(filepath.Join(cache, "iso", "*", "*.iso"))
+	for _, iso := range append(isos, archIsos...) {
+		label := "minikube ISO " + filepath.Base(iso)
+		if m := minikubeISORe.FindStringSubmatch(filepath.Base(iso)); m != nil {
+			label = "minikube ISO " + m[1]
+		}
+		c.addCached(iso, label, users[filepath.Base(iso)])
+	}
+
+	preloads, _ := filepath.Glob(filepath.Join(cache, "preloaded-tarball", "*.tar.lz4"))
+	for _, p := range preloads {
+		m := minikubePreloadRe.FindStringSubmatch(filepath.Base(p))
+		if m == nil {
+			c.addCached(p, "minikube preload "+filepath.Base(p), nil)
+			continue
+		}
+		c.addCached(p, "minikube preload Kubernetes "+m[1]+" ("+m[2]+")", users[m[1]])
+	}
+
+	kics, _ := filepath.Glob(filepath.Join(cache, "kic", "*", "kicbase*.tar"))
+	for _, k := range kics {
+		tag := kicbaseTag(k)
+		c.addCached(k, "minikube kicbase image "+tag, users[tag])
+	}
+
+	// kubectl, kubelet and kubeadm per version: cache/linux/<arch>/<version>
+	bins, _ := filepath.Glob(filepath.Join(cache, "linux", "*", "v*"))
+	for _, b := range bins {
+		version := filepath.Base(b)
+		c.addCached(b, "minikube binaries Kubernetes "+version, users[version])
+	}
+
+	// Images added with `minikube cache add` are there on purpose
+	if size, _ := simpleDirScan(filepath.Join(cache, "images")); size > 0 {
+		c.found = append(c.found, FileDetail{
+			Path:  filepath.Join(cache, "images"),
+			Size:  size,
+			Label: "minikube image cache (minikube cache add)",
+		})
+	}
+}
+
+// addCached lists a minikube cache file or dir, preselected unless a
+// profile uses it
+func (c *LocalKubeCleaner) addCached(path, label string, profiles []string) {
+	size, _ := simpleDirScan(path)
+	if info, err := os.Stat(path + ".checksum"); err == nil {
+		size += info.Size()
+	}
+	if size == 0 {
+		return
+	}
+	if len(profiles) > 0 {
+		label += " (used by " + strings.Join(profiles, ", ") + ")"
+	}
+	c.found = append(c.found, FileDetail{
+		Path:     path,
+		Size:     size,
+		Label:    label,
+		Selected: len(profiles) == 0,
+	})
+}
+
+// dockerImage is one line of `docker images`
+type dockerImage struct {
+	repo string
+	tag  string
+	id   string
+	size int64
+}
+
+// scanNodeImages lists the node and helper images of kind, k3d and the
+// minikube docker driver. Images no container was created from belong to
+// clusters that no longer exist.
+func (c *LocalKubeCleaner) scanNodeImages() {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return
+	}
+	clusters, err := dockerImageClusters()
+	if err != nil {
+		return // daemon not running or not accessible
+	}
+
+	type entry struct {
+		version string
+		detail  FileDetail
+	}
+	var entries []entry
+	for _, li := range localKubeImages {
+		out, err := exec.Command("docker", "images", "--format", "{{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.Size}}", li.repo).Output()
+		if err != nil {
+			continue
+		}
+		for _, img := range parseDockerImages(string(out)) {
+			ref := img.repo + ":" + img.tag
+			if img.tag == "<none>" {
+				ref = img.repo + "@" + img.id
+			}
+			label := li.tool + " image " + ref
+			version := kubeVersionRe.FindString(img.tag)
+			if li.node && version != "" {
+				label = li.tool + " node image Kubernetes " + version + " (" + ref + ")"
+			}
+
+			users := append(append([]string{}, clusters[ref]...), clusters[img.id]...)
+			if len(users) > 0 {
+				label += " (used by " + strings.Join(uniqueStrings(users), ", ") + ")"
+			}
+			path := "docker-image:" + ref
+			// An image ID with several tags cannot be removed by ID
+			c.images[path] = img.repo + ":" + img.tag
+			if img.tag == "<none>" {
+				c.images[path] = img.id
+			}
+			entries = append(entries, entry{version, FileDetail{
+				Path:     path,
+				Size:     img.size,
+				Label:    label,
+				Selected: len(users) == 0,
+			}})
+		}
+	}
+
+	// Newest Kubernetes version first
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareVersions(strings.TrimPrefix(entries[i].version, "v"), strings.TrimPrefix(entries[j].version, "v")) > 0
+	})
+	for _, e := range entries {
+		c.found = append(c.found, e.detail)
+	}
+}
+
+// dockerImageClusters maps the images of existing containers, running or
+// not, to the clusters they belong to. Containers outside any cluster are
+// listed under their own name.
+func dockerImageClusters() (map[string][]string, error) {
+	format := "{{.Image}}\t{{.Names}}"
+	for _, l := range localKubeClusterLabels {
+		format += "\t{{.Label \"" + l + "\"}}"
+	}
+	out, err := exec.Command("docker", "ps", "-a", "--format", format).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		name := fields[1]
+		for _, f := range fields[2:] {
+			if f != "" {
+				name = f
+				break
+			}
+		}
+		// Nodes are created from "kindest/node:v1.29.2@sha256:..."; the
+		// image shows as its ID once its tag is gone
+		image, _, _ := strings.Cut(fields[0], "@")
+		clusters[image] = append(clusters[image], name)
+		if !strings.Contains(image, ":") {
+			clusters[image+":latest"] = append(clusters[image+":latest"], name)
+		}
+	}
+	return clusters, nil
+}
+
+// parseDockerImages parses `docker images` lines of repository, tag, ID and
+// size separated by tabs. Docker reports decimal sizes ("1.2GB").
+func parseDockerImages(out string) []dockerImage {
+	var images []dockerImage
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		images = append(images, dockerImage{
+			repo: fields[0],
+			tag:  fields[1],
+			id:   fields[2],
+			size: parseDecimalSize(fields[3]),
+		})
+	}
+	return images
+}
+
+// uniqueStrings returns the distinct strings of s, sorted
+func uniqueStrings(s []string) []string {
+	set := make(map[string]bool)
+	for _, v := range s {
+		set[v] = true
+	}
+	return sortedKeys(set)
+}
+
+func (c *LocalKubeCleaner) Clean() error {
+	var errs []error
+	for _, t := range c.targets() {
+		if ref, ok := c.images[t.Path]; ok {
+			// Without -f docker refuses images a container still uses
+			if out, err := exec.Command("docker", "rmi", ref).CombinedOutput(); err != nil {
+				errs = append(errs, fmt.Errorf("docker rmi %s: %w: %s", ref, err, strings.TrimSpace(string(out))))
+			}
+			continue
+		}
+		if err := os.RemoveAll(t.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		_ = os.Remove(t.Path + ".checksum")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalKubeMinikubeCache(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"KicBaseImage": "gcr.io/k8s-minikube/kicbase:v0.0.42@sha256:d35a", "KubernetesConfig": {"KubernetesVersion": "v1.28.3"}}`
+	files := []string{
+		"cache/preloaded-tarball/preloaded-images-k8s-v18-v1.28.3-docker-overlay2-amd64.tar.lz4",
+		"cache/preloaded-tarball/preloaded-images-k8s-v18-v1.26.1-containerd-overlay2-amd64.tar.lz4",
+		"cache/kic/amd64/kicbase_v0.0.42@sha256_d35a.tar",
+		"cache/kic/amd64/kicbase_v0.0.40@sha256_8cad.tar",
+	}
+	contents := map[string]string{"profiles/dev/config.json": config}
+	for _, f := range files {
+		contents[f] = "data"
+	}
+	writeTestFiles(t, dir, contents)
+
+	c := &LocalKubeCleaner{}
+	c.scanMinikube(dir)
+	want := map[string]bool{files[0]: false, files[1]: true, files[2]: false, files[3]: true}
+	if len(c.found) != len(want) {
+		t.Fatalf("found %d entries, want %d", len(c.found), len(want))
+	}
+	for _, d := range c.found {
+		rel, _ := filepath.Rel(dir, d.Path)
+		if selected, ok := want[rel]; !ok || d.Selected != selected {
+			t.Errorf("%s (%s) selected = %v", rel, d.Label, d.Selected)
+		}
+	}
+}
+
+func TestLocalKubeNodeImages(t *testing.T) {
+	// A fake docker: two tags of one image, rmi fails for the second tag
+	bin := t.TempDir()
+	log := filepath.Join(bin, "rmi.log")
+	script := `#!/bin/sh
+case "$1" in
+images) [ "$4" = kindest/node ] && printf 'kindest/node\tv1.29.2\tabc123\t1GB\nkindest/node\tv1.28.0\tabc123\t1GB\n' ;;
+rmi) echo "$2" >> ` + log + `; [ "$2" = kindest/node:v1.29.2 ] || { echo "conflict" >&2; exit 1; } ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	c := &LocalKubeCleaner{images: make(map[string]string)}
+	c.scanNodeImages()
+	if len(c.found) != 2 {
+		t.Fatalf("found %v, want both tags", c.found)
+	}
+	err := c.Clean()
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Errorf("Clean() = %v, want the rmi error", err)
+	}
+	data, _ := os.ReadFile(log)
+	if got := strings.Fields(string(data)); strings.Join(got, " ") != "kindest/node:v1.29.2 kindest/node:v1.28.0" {
+		t.Errorf("docker rmi got %v, want both tags", got)
+	}
+}
+
+func TestParseDockerImages(t *testing.T) {
+	out := "kindest/node\tv1.29.2\tabc123\t1.2GB\nkindest/node\t<none>\tdef456\t745MB\nbroken line\n"
+	want := []dockerImage{
+		{repo: "kindest/node", tag: "v1.29.2", id: "abc123", size: 1200 * 1000 * 1000},
+		{repo: "kindest/node", tag: "<none>", id: "def456", size: 745 * 1000 * 1000},
+	}
+	got := parseDockerImages(out)
+	if len(got) != len(want) {
+		t.Fatalf("parseDockerImages() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseDockerImages()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cleaner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// languageCache describes where one toolchain keeps its download cache.
+// Paths are relative to the home directory and may contain glob patterns.
+// Builds hold compiled packages; they are listed but not preselected, since
+// rebuilding them takes far longer than downloading.
+type languageCache struct {
+	name   string
+	tools  []string
+	paths  []string
+	builds []string
+}
+
+// languageCaches is the table LanguageCacheCleaner works from. Supporting a
+// new ecosystem only needs a new entry here. Only pure caches are listed:
+// installed gems, GHC toolchains or globally activated pub packages live
+// next to them and must survive.
+var languageCaches = []languageCache{
+	{"RubyGems", []string{"gem"}, []string{
+		".gem/specs",
+		".gem/ruby/*/cache",
+		".local/share/gem/specs",
+		".local/share/gem/ruby/*/cache",
+	}, nil},
+	{"Composer", []string{"composer"}, []string{".composer/cache", ".cache/composer"}, nil},
+	{"NuGet", []string{"dotnet", "nuget"}, []string{".nuget/packages"}, nil},
+	{"Haskell Stack", []string{"stack"}, []string{".stack/pantry"}, []string{".stack/snapshots"}},
+	{"Cabal", []string{"cabal"}, []string{".cabal/packages"}, nil},
+	{"opam", []string{"opam"}, []string{".opam/download-cache"}, nil},
+	{"Dart pub", []string{"dart", "flutter"}, []string{".pub-cache/hosted", ".pub-cache/git"}, nil},
+}
+
+// LanguageCacheCleaner reports the package caches of toolchains that keep
+// them outside ~/.cache, one entry per cache directory.
+type LanguageCacheCleaner struct {
+	detailSet
+}
+
+func (c *LanguageCacheCleaner) Name() string {
+	return "Language Package Caches"
+}
+
+func (c *LanguageCacheCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *LanguageCacheCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	c.found = nil
+	for _, lc := range languageCaches {
+		installed := false
+		for _, t := range lc.tools {
+			if _, err := exec.LookPath(t); err == nil {
+				installed = true
+				break
+			}
+		}
+
+		builds := make(map[string]bool)
+		for _, pattern := range lc.builds {
+			builds[pattern] = true
+		}
+		for _, pattern := range append(append([]string{}, lc.paths...), lc.builds...) {
+			matches, _ := filepath.Glob(filepath.Join(home, pattern))
+			for _, p := range matches {
+				info, err := os.Stat(p)
+				if err != nil || !info.IsDir() {
+					continue
+				}
+				size, _ := simpleDirScan(p)
+				if size == 0 {
+					continue
+				}
+
+				label := lc.name + ": ~/" + strings.TrimPrefix(p, home+string(os.PathSeparator))
+				if !installed {
+					label += " (toolchain not installed)"
+				}
+				detail := FileDetail{
+					Path:     p,
+					Size:     size,
+					Label:    label,
+					Selected: !builds[pattern],
+				}
+				if builds[pattern] {
+					detail.Warning = "built packages, slow to rebuild"
+				}
+				c.found = append(c.found, detail)
+			}
+		}
+	}
+	return totalSize(c.found), nil
+}
+
+func (c *LanguageCacheCleaner) Clean() error {
+	for _, t := range c.targets() {
+		// The toolchains re-download packages on demand
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
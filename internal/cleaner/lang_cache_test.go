@@ -0,0 +1,52 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLanguageCacheScan(t *testing.T) {
+	files := map[string]string{
+		".gem/ruby/3.2.0/cache/rake-13.0.gem":     "gem",
+		".gem/ruby/3.2.0/gems/rake-13.0/lib/a.rb": "installed",
+		".stack/pantry/pantry.sqlite3":            "pantry",
+		".stack/snapshots/x86_64-linux/lts/pkg":   "built",
+		".stack/programs/ghc-9.4.7/bin/ghc":       "toolchain",
+	}
+	want := map[string]bool{
+		".gem/ruby/3.2.0/cache": true,
+		".stack/pantry":         true,
+		".stack/snapshots":      false,
+	}
+	runScanCases(t, func() DetailCleaner { return &LanguageCacheCleaner{} }, []scanCase{
+		{
+			name:  "toolchains missing",
+			files: files,
+			env:   map[string]string{"PATH": "$HOME/bin"},
+			want:  want,
+			labels: map[string]string{
+				".gem/ruby/3.2.0/cache": "RubyGems: ~/.gem/ruby/3.2.0/cache (toolchain not installed)",
+				".stack/pantry":         "Haskell Stack: ~/.stack/pantry (toolchain not installed)",
+			},
+		},
+		{
+			name:  "toolchains installed",
+			files: files,
+			env:   map[string]string{"PATH": "$HOME/bin"},
+			setup: func(t *testing.T, home string) {
+				for _, tool := range []string{"gem", "stack"} {
+					writeTestFiles(t, home, map[string]string{"bin/" + tool: "#!/bin/sh\n"})
+					if err := os.Chmod(filepath.Join(home, "bin", tool), 0o755); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			want: want,
+			labels: map[string]string{
+				".gem/ruby/3.2.0/cache": "RubyGems: ~/.gem/ruby/3.2.0/cache",
+				".stack/pantry":         "Haskell Stack: ~/.stack/pantry",
+			},
+		},
+	})
+}
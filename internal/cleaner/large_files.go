@@ -7,11 +7,6 @@ import (
 	"time"
 )
 
-type FileDetail struct {
-	Path string
-	Size int64
-}
-
 type LargeFileCleaner struct {
 	FoundFiles       []FileDetail
 	FilesToClean     []string // If set, only clean these. If empty, clean all found?
@@ -64,6 +59,10 @@ func (c *LargeFileCleaner) Scan() (int64, error) {
 	return size, nil
 }
 
+func (c *LargeFileCleaner) Details() []FileDetail {
+	return c.FoundFiles
+}
+
 func (c *LargeFileCleaner) SetFilesToClean(paths []string) {
 	c.FilesToClean = paths
 }
@@ -0,0 +1,290 @@
+package cleaner
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// leftoverMinConfidence is the score below which a dir is not reported
+const leftoverMinConfidence = 40
+
+// leftoverAliases maps dir names that differ from every name the owning
+// app is installed under to those names
+var leftoverAliases = map[string][]string{
+	"Code":          {"code", "code-oss", "codium", "com.visualstudio.code"},
+	"VSCodium":      {"codium", "com.vscodium.codium"},
+	"google-chrome": {"google-chrome-stable", "chrome", "com.google.Chrome"},
+	"BraveSoftware": {"brave-browser", "brave", "com.brave.Browser"},
+	"mozilla":       {"firefox", "thunderbird", "org.mozilla.firefox"},
+	"thunderbird":   {"thunderbird", "org.mozilla.Thunderbird"},
+	"libreoffice":   {"soffice", "libreoffice", "org.libreoffice.LibreOffice"},
+	"obs-studio":    {"obs", "com.obsproject.Studio"},
+	"Signal":        {"signal-desktop", "org.signal.Signal"},
+	"Element":       {"element-desktop", "im.riot.Riot"},
+	"Slack":         {"slack", "com.slack.Slack"},
+	"discord":       {"discord", "com.discordapp.Discord"},
+	"spotify":       {"spotify", "com.spotify.Client"},
+	"Microsoft":     {"teams", "teams-for-linux", "msedge", "microsoft-edge"},
+	"Mattermost":    {"mattermost-desktop"},
+	"Postman":       {"postman", "com.getpostman.Postman"},
+	"transmission":  {"transmission-gtk", "transmission-qt", "transmission-daemon"},
+	"JetBrains":     {"idea", "pycharm", "goland", "clion", "webstorm", "rider", "jetbrains-toolbox"},
+	"pip":           {"pip", "pip3"},
+	"go-build":      {"go"},
+	"GitKraken":     {"gitkraken", "com.axosoft.GitKraken"},
+}
+
+// leftoverSystemDirs are created by the desktop or libraries rather than by
+// one application, so a missing "owner" means nothing
+var leftoverSystemDirs = map[string]bool{
+	"applications": true, "autostart": true, "dconf": true, "fontconfig": true,
+	"fonts": true, "gtk-2.0": true, "gtk-3.0": true, "gtk-4.0": true,
+	"icons": true, "keyrings": true, "menus": true, "mime": true,
+	"pulse": true, "systemd": true, "Trash": true, "thumbnails": true,
+	"xdg-desktop-portal": true, "environment.d": true, "session": true,
+	"sessions": true, "ibus": true, "flatpak": true, "themes": true,
+	"sounds": true, "backgrounds": true, "gvfs-metadata": true, "tracker3": true,
+	"nautilus": true, "evolution": true, "gnome-shell": true, "kwalletd": true,
+	"mesa_shader_cache": true, "mesa_shader_cache_db": true, "nvidia": true,
+}
+
+// LeftoverCleaner looks for dirs in ~/.config, ~/.cache and ~/.local/share
+// that no installed application seems to own. The installed inventory is
+// built from .desktop files, $PATH, dpkg/rpm file lists and Flatpak IDs.
+// Matching is heuristic, so every entry carries a confidence score and
+// nothing is preselected.
+type LeftoverCleaner struct {
+	detailSet
+}
+
+func (c *LeftoverCleaner) Name() string {
+	return "Leftovers of Uninstalled Apps"
+}
+
+func (c *LeftoverCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *LeftoverCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	inventory := installedInventory(home)
+	procs := SnapshotProcesses()
+
+	c.found = nil
+	for _, root := range []string{
+		filepath.Join(home, ".config"),
+		filepath.Join(home, ".cache"),
+		filepath.Join(home, ".local", "share"),
+	} {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !e.IsDir() || strings.HasPrefix(name, ".") || leftoverSystemDirs[name] || strings.HasPrefix(name, "gnome-") {
+				continue
+			}
+			path := filepath.Join(root, name)
+			if procs.InUse(path) {
+				continue
+			}
+
+			size, modified := dirSizeAndModTime(path)
+			confidence := leftoverConfidence(name, inventory, modified)
+			if size == 0 || confidence < leftoverMinConfidence {
+				continue
+			}
+
+			rel, _ := filepath.Rel(home, path)
+			c.found = append(c.found, FileDetail{
+				Path: path,
+				Size: size,
+				Label: "~/" + rel + " (" + strconv.Itoa(confidence) + "% likely orphaned, last modified " +
+					modified.Format("2006-01-02") + ")",
+			})
+		}
+	}
+
+	sort.SliceStable(c.found, func(i, j int) bool { return c.found[i].Size > c.found[j].Size })
+	return totalSize(c.found), nil
+}
+
+// leftoverConfidence scores how likely the dir name belongs to no installed
+// application: 0 when an owner was found, up to 100 for an old dir of an app
+// we know by its alias and which is definitely gone
+func leftoverConfidence(name string, inventory map[string]bool, modified time.Time) int {
+	candidates := append([]string{name}, leftoverAliases[name]...)
+	confidence := 50
+	for _, cand := range candidates {
+		key := normalizeAppName(cand)
+		if key == "" || inventory[key] {
+			return 0
+		}
+		// A partial match, e.g. "google-chrome" vs "google-chrome-stable"
+		for inst := range inventory {
+			if len(key) >= 4 && len(inst) >= 4 && (strings.HasPrefix(inst, key) || strings.HasPrefix(key, inst)) {
+				confidence = 10
+			}
+		}
+	}
+	if _, known := leftoverAliases[name]; known && confidence > 10 {
+		confidence += 15
+	}
+
+	age := time.Since(modified)
+	switch {
+	case age > 365*24*time.Hour:
+		confidence += 35
+	case age > 90*24*time.Hour:
+		confidence += 15
+	case age < 30*24*time.Hour:
+		// Something still writes here
+		confidence -= 30
+	}
+	if confidence > 100 {
+		confidence = 100
+	}
+	if confidence < 0 {
+		confidence = 0
+	}
+	return confidence
+}
+
+// normalizeAppName reduces app, package and dir names to a comparable key:
+// the last reverse-DNS component, lower case, letters and digits only
+func normalizeAppName(s string) string {
+	s = strings.TrimSuffix(s, ".desktop")
+	if strings.Count(s, ".") >= 2 {
+		s = s[strings.LastIndex(s, ".")+1:]
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// installedInventory collects the normalized names of everything installed
+func installedInventory(home string) map[string]bool {
+	inv := make(map[string]bool)
+	add := func(name string) {
+		if key := normalizeAppName(name); key != "" {
+			inv[key] = true
+		}
+	}
+
+	// Desktop entries: the file ID, the binary and the window class
+	for _, dir := range []string{
+		"/usr/share/applications",
+		"/usr/local/share/applications",
+		"/var/lib/flatpak/exports/share/applications",
+		"/var/lib/snapd/desktop/applications",
+		filepath.Join(home, ".local", "share", "applications"),
+		filepath.Join(home, ".local", "share", "flatpak", "exports", "share", "applications"),
+	} {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		for _, f := range files {
+			add(filepath.Base(f))
+			for key, value := range desktopEntryKeys(f, "Exec", "StartupWMClass") {
+				if key == "Exec" {
+					if fields := strings.Fields(value); len(fields) > 0 {
+						value = filepath.Base(fields[0])
+					}
+				}
+				add(value)
+			}
+		}
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			add(e.Name())
+		}
+	}
+
+	// dpkg: package names plus the app dirs their files live in
+	lists, _ := filepath.Glob("/var/lib/dpkg/info/*.list")
+	for _, l := range lists {
+		pkg, _, _ := strings.Cut(strings.TrimSuffix(filepath.Base(l), ".list"), ":")
+		add(pkg)
+		if f, err := os.Open(l); err == nil {
+			addPackageDirs(bufio.NewScanner(f), add)
+			f.Close()
+		}
+	}
+
+	if _, err := exec.LookPath("rpm"); err == nil {
+		if out, err := exec.Command("rpm", "-qa", "--qf", "%{NAME}\n").Output(); err == nil {
+			for _, pkg := range strings.Fields(string(out)) {
+				add(pkg)
+			}
+		}
+		if out, err := exec.Command("rpm", "-qal").Output(); err == nil {
+			addPackageDirs(bufio.NewScanner(strings.NewReader(string(out))), add)
+		}
+	}
+
+	for _, dir := range []string{"/var/lib/flatpak/app", filepath.Join(home, ".local", "share", "flatpak", "app")} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			add(e.Name())
+		}
+	}
+	return inv
+}
+
+// addPackageDirs adds the app specific dir of every packaged file, e.g.
+// "vlc" for /usr/share/vlc/... or /etc/vlc/...
+func addPackageDirs(scanner *bufio.Scanner, add func(string)) {
+	for scanner.Scan() {
+		for _, prefix := range []string{"/usr/share/", "/usr/lib/", "/etc/", "/opt/"} {
+			rest, ok := strings.CutPrefix(scanner.Text(), prefix)
+			if !ok {
+				continue
+			}
+			if dir, _, found := strings.Cut(rest, "/"); found {
+				add(dir)
+			}
+		}
+	}
+}
+
+// dirSizeAndModTime returns the size of a tree and its newest modification
+func dirSizeAndModTime(path string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return size, newest
+}
+
+func (c *LeftoverCleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
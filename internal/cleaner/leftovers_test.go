@@ -0,0 +1,32 @@
+package cleaner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeftoverConfidence(t *testing.T) {
+	inventory := map[string]bool{"code": true, "googlechromestable": true, "transmissioncli": true, "vim": true}
+	old := time.Now().Add(-2 * 365 * 24 * time.Hour)
+	recent := time.Now()
+
+	tests := []struct {
+		name     string
+		modified time.Time
+		want     int
+	}{
+		{"vim", old, 0},
+		{"Code", old, 0},
+		{"org.vim.Vim", old, 0},
+		{"google-chrome", old, 0},
+		{"transmission", old, 45},
+		{"Slack", old, 100},
+		{"oldapp", old, 85},
+		{"oldapp", recent, 20},
+	}
+	for _, tt := range tests {
+		if got := leftoverConfidence(tt.name, inventory, tt.modified); got != tt.want {
+			t.Errorf("leftoverConfidence(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
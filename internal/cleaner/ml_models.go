@@ -0,0 +1,378 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type mlModelKind int
+
+const (
+	mlHFRepo mlModelKind = iota
+	mlHFRevision
+	mlTorchHub
+	mlOllama
+)
+
+// mlModel is one deletable model found during Scan
+type mlModel struct {
+	kind mlModelKind
+	// blobs are the content files this entry owns (HF revisions, Ollama)
+	blobs []string
+	// name is the Ollama model reference passed to `ollama rm`
+	name string
+}
+
+// MLModelCleaner lists individual models in the Hugging Face, PyTorch Hub
+// and Ollama caches. Nothing is preselected; shared blobs are only removed
+// once no remaining model references them.
+type MLModelCleaner struct {
+	detailSet
+
+	models map[string]mlModel
+	// ollamaRefs maps each Ollama blob to the manifests that use it
+	ollamaRefs map[string][]string
+}
+
+func (c *MLModelCleaner) Name() string {
+	return "ML Model Caches"
+}
+
+func (c *MLModelCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *MLModelCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	c.found = nil
+	c.models = make(map[string]mlModel)
+	c.ollamaRefs = make(map[string][]string)
+
+	c.scanHuggingFace(huggingFaceHubDir(home))
+	c.scanTorchHub(torchHubDir(home))
+	c.scanOllama(ollamaModelsDir(home))
+
+	return totalSize(c.found), nil
+}
+
+func (c *MLModelCleaner) add(path string, size int64, label string, lastUsed time.Time, m mlModel) {
+	if size == 0 {
+		return
+	}
+	if !lastUsed.IsZero() {
+		label += ", last used " + lastUsed.Format("2006-01-02")
+	}
+	c.models[path] = m
+	c.found = append(c.found, FileDetail{Path: path, Size: size, Label: label})
+}
+
+func huggingFaceHubDir(home string) string {
+	if dir := os.Getenv("HUGGINGFACE_HUB_CACHE"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("HF_HOME"); dir != "" {
+		return filepath.Join(dir, "hub")
+	}
+	return filepath.Join(home, ".cache", "huggingface", "hub")
+}
+
+func torchHubDir(home string) string {
+	if dir := os.Getenv("TORCH_HOME"); dir != "" {
+		return filepath.Join(dir, "hub")
+	}
+	return filepath.Join(home, ".cache", "torch", "hub")
+}
+
+func ollamaModelsDir(home string) string {
+	if dir := os.Getenv("OLLAMA_MODELS"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".ollama", "models")
+}
+
+// scanHuggingFace lists each "models--org--name" (or datasets/spaces) repo.
+// Revisions that no ref points to anymore are listed separately, sized by the
+// blobs only they use; those bytes are left out of the repo entry so the two
+// never count twice.
+func (c *MLModelCleaner) scanHuggingFace(hub string) {
+	repos, err := os.ReadDir(hub)
+	if err != nil {
+		return
+	}
+
+	for _, r := range repos {
+		kind, name, ok := strings.Cut(r.Name(), "--")
+		if !ok || !r.IsDir() {
+			continue
+		}
+		repoDir := filepath.Join(hub, r.Name())
+		display := fmt.Sprintf("HF %s %s", strings.TrimSuffix(kind, "s"), strings.ReplaceAll(name, "--", "/"))
+
+		size, lastUsed := blobStats(filepath.Join(repoDir, "blobs"))
+		revisions := huggingFaceRevisions(repoDir, display)
+		for _, r := range revisions {
+			size -= r.size
+		}
+		c.add(repoDir, size, display, lastUsed, mlModel{kind: mlHFRepo})
+		for _, r := range revisions {
+			c.add(r.path, r.size, r.label, r.lastUsed, r.model)
+		}
+	}
+}
+
+// hfRevision is an unreferenced Hugging Face snapshot and the blobs only it uses
+type hfRevision struct {
+	path     string
+	size     int64
+	label    string
+	lastUsed time.Time
+	model    mlModel
+}
+
+// huggingFaceRevisions returns the snapshots of a repo that no ref points to
+func huggingFaceRevisions(repoDir, display string) []hfRevision {
+	// Snapshots named in refs/ are the ones the hub currently resolves to
+	referenced := make(map[string]bool)
+	refFiles, _ := filepath.Glob(filepath.Join(repoDir, "refs", "*"))
+	for _, rf := range refFiles {
+		if data, err := os.ReadFile(rf); err == nil {
+			referenced[strings.TrimSpace(string(data))] = true
+		}
+	}
+
+	snapshots, _ := os.ReadDir(filepath.Join(repoDir, "snapshots"))
+	if len(snapshots) < 2 {
+		return nil
+	}
+	// Map each snapshot to the blobs its files link to
+	blobUsers := make(map[string]int)
+	snapshotBlobs := make(map[string][]string)
+	for _, s := range snapshots {
+		snapDir := filepath.Join(repoDir, "snapshots", s.Name())
+		filepath.Walk(snapDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				return nil
+			}
+			if target, err := os.Readlink(path); err == nil {
+				blob := filepath.Join(repoDir, "blobs", filepath.Base(target))
+				snapshotBlobs[s.Name()] = append(snapshotBlobs[s.Name()], blob)
+				blobUsers[blob]++
+			}
+			return nil
+		})
+	}
+
+	var revisions []hfRevision
+	for _, s := range snapshots {
+		if referenced[s.Name()] {
+			continue
+		}
+		var owned []string
+		var ownedSize int64
+		var last time.Time
+		for _, b := range snapshotBlobs[s.Name()] {
+			if blobUsers[b] != 1 {
+				continue
+			}
+			if info, err := os.Stat(b); err == nil {
+				owned = append(owned, b)
+				ownedSize += info.Size()
+				if at := accessTime(info); at.After(last) {
+					last = at
+				}
+			}
+		}
+		rev := s.Name()
+		if len(rev) > 8 {
+			rev = rev[:8]
+		}
+		revisions = append(revisions, hfRevision{
+			path:     filepath.Join(repoDir, "snapshots", s.Name()),
+			size:     ownedSize,
+			label:    display + " @ " + rev + " (unreferenced revision)",
+			lastUsed: last,
+			model:    mlModel{kind: mlHFRevision, blobs: owned},
+		})
+	}
+	return revisions
+}
+
+// scanTorchHub lists downloaded checkpoints and cloned hub repos
+func (c *MLModelCleaner) scanTorchHub(hub string) {
+	checkpoints, _ := os.ReadDir(filepath.Join(hub, "checkpoints"))
+	for _, cp := range checkpoints {
+		info, err := cp.Info()
+		if err != nil || cp.IsDir() {
+			continue
+		}
+		c.add(filepath.Join(hub, "checkpoints", cp.Name()), info.Size(),
+			"Torch checkpoint "+cp.Name(), accessTime(info), mlModel{kind: mlTorchHub})
+	}
+
+	repos, _ := os.ReadDir(hub)
+	for _, r := range repos {
+		if !r.IsDir() || r.Name() == "checkpoints" {
+			continue
+		}
+		path := filepath.Join(hub, r.Name())
+		size, lastUsed := blobStats(path)
+		c.add(path, size, "Torch hub repo "+r.Name(), lastUsed, mlModel{kind: mlTorchHub})
+	}
+}
+
+// ollamaManifest is the subset of an Ollama manifest we need
+type ollamaManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+}
+
+// scanOllama lists every model:tag manifest. Base layers are shared between
+// models, so each entry is sized by the blobs no other manifest uses.
+func (c *MLModelCleaner) scanOllama(root string) {
+	manifestsDir := filepath.Join(root, "manifests")
+	manifests := make(map[string][]string)
+	filepath.Walk(manifestsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var m ollamaManifest
+		if json.Unmarshal(data, &m) != nil {
+			return nil
+		}
+		digests := []string{m.Config.Digest}
+		for _, l := range m.Layers {
+			digests = append(digests, l.Digest)
+		}
+		for _, d := range digests {
+			if d == "" {
+				continue
+			}
+			blob := filepath.Join(root, "blobs", strings.Replace(d, ":", "-", 1))
+			manifests[path] = append(manifests[path], blob)
+			c.ollamaRefs[blob] = append(c.ollamaRefs[blob], path)
+		}
+		return nil
+	})
+
+	paths := make([]string, 0, len(manifests))
+	for path := range manifests {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		blobs := manifests[path]
+		var size int64
+		var last time.Time
+		for _, b := range blobs {
+			info, err := os.Stat(b)
+			if err != nil {
+				continue
+			}
+			if len(c.ollamaRefs[b]) == 1 {
+				size += info.Size()
+			}
+			if at := accessTime(info); at.After(last) {
+				last = at
+			}
+		}
+		name := ollamaModelName(manifestsDir, path)
+		c.add(path, size, "Ollama "+name, last, mlModel{kind: mlOllama, blobs: blobs, name: name})
+	}
+}
+
+// ollamaModelName turns manifests/registry.ollama.ai/library/llama3/8b into "llama3:8b"
+func ollamaModelName(manifestsDir, path string) string {
+	rel, err := filepath.Rel(manifestsDir, path)
+	if err != nil {
+		return path
+	}
+	parts := strings.Split(rel, string(os.PathSeparator))
+	if len(parts) != 4 {
+		return rel
+	}
+	name := parts[2] + ":" + parts[3]
+	if parts[0] != "registry.ollama.ai" || parts[1] != "library" {
+		name = parts[0] + "/" + parts[1] + "/" + name
+	}
+	return name
+}
+
+// blobStats returns the total size and latest access time of files below dir
+func blobStats(dir string) (int64, time.Time) {
+	var size int64
+	var last time.Time
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		size += info.Size()
+		if at := accessTime(info); at.After(last) {
+			last = at
+		}
+		return nil
+	})
+	return size, last
+}
+
+func (c *MLModelCleaner) Clean() error {
+	removedManifests := make(map[string]bool)
+	var ollamaBlobs []string
+
+	for _, t := range c.targets() {
+		m := c.models[t.Path]
+		switch m.kind {
+		case mlHFRepo, mlTorchHub:
+			_ = os.RemoveAll(t.Path)
+		case mlHFRevision:
+			_ = os.RemoveAll(t.Path)
+			for _, b := range m.blobs {
+				_ = os.Remove(b)
+			}
+		case mlOllama:
+			// `ollama rm` needs the server; fall back to removing the manifest ourselves
+			if _, err := exec.LookPath("ollama"); err == nil {
+				if exec.Command("ollama", "rm", m.name).Run() == nil {
+					continue
+				}
+			}
+			if err := os.Remove(t.Path); err == nil {
+				removedManifests[t.Path] = true
+				ollamaBlobs = append(ollamaBlobs, m.blobs...)
+			}
+		}
+	}
+
+	// Drop Ollama blobs that no surviving manifest references
+	for _, b := range ollamaBlobs {
+		inUse := false
+		for _, ref := range c.ollamaRefs[b] {
+			if !removedManifests[ref] {
+				inUse = true
+				break
+			}
+		}
+		if !inUse {
+			_ = os.Remove(b)
+		}
+	}
+	return nil
+}
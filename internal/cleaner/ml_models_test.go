@@ -0,0 +1,45 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHuggingFaceRevisions(t *testing.T) {
+	hub := t.TempDir()
+	repo := filepath.Join(hub, "models--org--model")
+	writeTestFiles(t, repo, map[string]string{
+		"blobs/aaaa":  strings.Repeat("a", 10),
+		"blobs/bbbb":  strings.Repeat("b", 20),
+		"refs/main":   "rev1\n",
+		"blobs/.lock": "",
+	})
+	for rev, blob := range map[string]string{"rev1": "aaaa", "rev2": "bbbb"} {
+		dir := filepath.Join(repo, "snapshots", rev)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..", "..", "blobs", blob), filepath.Join(dir, "model.bin")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &MLModelCleaner{models: make(map[string]mlModel)}
+	c.scanHuggingFace(hub)
+	sizes := make(map[string]int64)
+	for _, d := range c.found {
+		sizes[d.Path] = d.Size
+	}
+	// The unreferenced revision's blob is not counted in the repo too
+	want := map[string]int64{repo: 10, filepath.Join(repo, "snapshots", "rev2"): 20}
+	if len(sizes) != len(want) || totalSize(c.found) != 30 {
+		t.Fatalf("entries = %v, want %v", sizes, want)
+	}
+	for p, size := range want {
+		if sizes[p] != size {
+			t.Errorf("%s size = %d, want %d", p, sizes[p], size)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNixKeepLast is how many generations per profile are kept when
+// neither KeepLast nor KeepDays is set
+const DefaultNixKeepLast = 3
+
+// nixStore stands for the store garbage in the entry list
+const nixStore = "/nix/store"
+
+// nixSizeBatch is how many store paths are sized per nix-store call
+const nixSizeBatch = 500
+
+// nixGenerationRe matches generation links such as "profile-42-link"
+var nixGenerationRe = regexp.MustCompile(`^(.+)-(\d+)-link$`)
+
+// nixGeneration is one generation link of a profile
+type nixGeneration struct {
+	profile string
+	number  int
+	link    string
+	date    time.Time
+}
+
+// NixCleaner lists the generations of the user, system and home-manager
+// profiles with their dates, and the dead paths in /nix/store. Generations
+// beyond the newest KeepLast, the current one included, that are older than
+// KeepDays are preselected; the current one is never listed. Removed
+// generations free nothing until the store is collected, which the separate
+// "Nix store garbage" entry does.
+type NixCleaner struct {
+	detailSet
+
+	// KeepLast is how many of the newest generations per profile are kept
+	KeepLast int
+	// KeepDays also keeps generations younger than this many days
+	KeepDays int
+
+	generations map[string]nixGeneration
+}
+
+func (c *NixCleaner) Name() string {
+	return "Nix Store & Generations"
+}
+
+func (c *NixCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *NixCleaner) Scan() (int64, error) {
+	if !dirExists(nixStore) || nixTool("nix-store") == "" {
+		return 0, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	keepLast, keepDays := c.KeepLast, c.KeepDays
+	if keepLast <= 0 && keepDays <= 0 {
+		keepLast = DefaultNixKeepLast
+	}
+
+	c.found = nil
+	c.generations = make(map[string]nixGeneration)
+
+	var currents, old []nixGeneration
+	preselected := make(map[string]bool)
+	kinds := make(map[string]string)
+	for _, p := range nixProfiles(home) {
+		gens, current := nixGenerations(p.path)
+		for i, g := range gens {
+			kinds[g.link] = p.kind
+			if g.number == current {
+				currents = append(currents, g)
+				continue
+			}
+			old = append(old, g)
+			preselected[g.link] = !nixKeep(i, g.date, keepLast, keepDays)
+		}
+	}
+
+	// What a generation frees is the part of its closure no current
+	// generation needs; paths shared by old ones count for the oldest
+	sizes := newNixSizes()
+	protected := make(map[string]bool)
+	for _, g := range currents {
+		for _, p := range nixClosure(g.link) {
+			protected[p] = true
+		}
+	}
+	sort.SliceStable(old, func(i, j int) bool { return old[i].date.Before(old[j].date) })
+	for _, g := range old {
+		var own []string
+		for _, p := range nixClosure(g.link) {
+			if !protected[p] {
+				protected[p] = true
+				own = append(own, p)
+			}
+		}
+
+		current := ""
+		for _, cg := range currents {
+			if cg.profile == g.profile {
+				current = fmt.Sprintf(", current is %d", cg.number)
+			}
+		}
+		detail := FileDetail{
+			Path:     g.link,
+			Size:     sizes.total(own),
+			Label:    fmt.Sprintf("%s generation %d (%s%s)", kinds[g.link], g.number, g.date.Format("2006-01-02"), current),
+			Selected: preselected[g.link],
+		}
+		if !removable(g.link) {
+			detail.Warning = "needs root"
+			detail.Selected = false
+		}
+		c.generations[g.link] = g
+		c.found = append(c.found, detail)
+	}
+
+	// Listed with old generations too: collecting is what frees their paths
+	if dead := nixDeadPaths(); len(dead) > 0 || len(old) > 0 {
+		c.found = append(c.found, FileDetail{
+			Path:     nixStore,
+			Size:     sizes.total(dead),
+			Label:    fmt.Sprintf("Nix store garbage (%d dead paths and those of removed generations, nix-store --gc)", len(dead)),
+			Selected: true,
+		})
+	}
+	return totalSize(c.found), nil
+}
+
+// nixProfile is a profile symlink and what kind of profile it is
+type nixProfile struct {
+	kind string
+	path string
+}
+
+// nixProfiles returns the profiles that exist, in both the XDG location of
+// Nix 2.14+ and the older per-user dir. The links may point to each other.
+func nixProfiles(home string) []nixProfile {
+	user := os.Getenv("USER")
+	state := filepath.Join(home, ".local", "state", "nix", "profiles")
+	perUser := filepath.Join("/nix/var/nix/profiles/per-user", user)
+	candidates := []nixProfile{
+		{"user", filepath.Join(state, "profile")},
+		{"home-manager", filepath.Join(state, "home-manager")},
+		{"system", "/nix/var/nix/profiles/system"},
+		{"default", "/nix/var/nix/profiles/default"},
+	}
+	if user != "" {
+		candidates = append(candidates,
+			nixProfile{"user", filepath.Join(perUser, "profile")},
+			nixProfile{"home-manager", filepath.Join(perUser, "home-manager")},
+		)
+	}
+
+	seen := make(map[string]bool)
+	var profiles []nixProfile
+	for _, p := range candidates {
+		if _, err := os.Lstat(p.path); err != nil || seen[p.path] {
+			continue
+		}
+		seen[p.path] = true
+		profiles = append(profiles, p)
+	}
+	return profiles
+}
+
+// nixGenerations returns the generation links of profile, newest first, and
+// the number of the current one
+func nixGenerations(profile string) ([]nixGeneration, int) {
+	dir, base := filepath.Dir(profile), filepath.Base(profile)
+	current := -1
+	if target, err := os.Readlink(profile); err == nil {
+		if m := nixGenerationRe.FindStringSubmatch(filepath.Base(target)); m != nil && m[1] == base {
+			current, _ = strconv.Atoi(m[2])
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, current
+	}
+	var gens []nixGeneration
+	for _, e := range entries {
+		m := nixGenerationRe.FindStringSubmatch(e.Name())
+		if m == nil || m[1] != base {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		gens = append(gens, nixGeneration{
+			profile: profile,
+			number:  n,
+			link:    filepath.Join(dir, e.Name()),
+			date:    info.ModTime(),
+		})
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i].number > gens[j].number })
+	return gens, current
+}
+
+// nixKeep reports whether the generation at position i, counted from the
+// newest, is kept: it is among the newest keepLast or younger than keepDays
+func nixKeep(i int, date time.Time, keepLast, keepDays int) bool {
+	if i < keepLast {
+		return true
+	}
+	return keepDays > 0 && time.Since(date) < time.Duration(keepDays)*24*time.Hour
+}
+
+// nixTool finds a nix command, also when the nix profile is not in PATH, as
+// under sudo
+func nixTool(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	path := filepath.Join("/nix/var/nix/profiles/default/bin", name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return ""
+}
+
+// nixClosure returns the store paths path depends on, itself included
+func nixClosure(path string) []string {
+	out, err := exec.Command(nixTool("nix-store"), "--query", "--requisites", path).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// nixDeadPaths returns what `nix-store --gc` would delete right now
+func nixDeadPaths() []string {
+	out, err := exec.Command(nixTool("nix-store"), "--gc", "--print-dead").Output()
+	if err != nil {
+		return nil
+	}
+	var dead []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, nixStore+"/") {
+			dead = append(dead, line)
+		}
+	}
+	return dead
+}
+
+// nixSizes looks up and caches the NAR size of store paths
+type nixSizes map[string]int64
+
+func newNixSizes() nixSizes {
+	return make(nixSizes)
+}
+
+// total returns the summed size of paths, querying unknown ones in batches
+func (s nixSizes) total(paths []string) int64 {
+	var unknown []string
+	for _, p := range paths {
+		if _, ok := s[p]; !ok {
+			unknown = append(unknown, p)
+		}
+	}
+	for len(unknown) > 0 {
+		batch := unknown[:min(nixSizeBatch, len(unknown))]
+		unknown = unknown[len(batch):]
+		out, err := exec.Command(nixTool("nix-store"), append([]string{"--query", "--size"}, batch...)...).Output()
+		sizes := strings.Fields(string(out))
+		for i, p := range batch {
+			s[p] = 0
+			if err == nil && i < len(sizes) {
+				s[p], _ = strconv.ParseInt(sizes[i], 10, 64)
+			}
+		}
+	}
+
+	var total int64
+	for _, p := range paths {
+		total += s[p]
+	}
+	return total
+}
+
+func (c *NixCleaner) Clean() error {
+	byProfile := make(map[string][]string)
+	collect := false
+	for _, t := range c.targets() {
+		if t.Path == nixStore {
+			collect = true
+		} else if g, ok := c.generations[t.Path]; ok {
+			byProfile[g.profile] = append(byProfile[g.profile], strconv.Itoa(g.number))
+		}
+	}
+
+	var errs []error
+	for _, profile := range sortedKeys(byProfile) {
+		args := append([]string{"--profile", profile, "--delete-generations"}, byProfile[profile]...)
+		if out, err := exec.Command(nixTool("nix-env"), args...).CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", profile, err, strings.TrimSpace(string(out))))
+		}
+	}
+	// Runs after the generations are gone, so their paths are collected too
+	if collect {
+		if out, err := exec.Command(nixTool("nix-store"), "--gc").CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("nix-store --gc: %w: %s", err, strings.TrimSpace(string(out))))
+		}
+	}
+	return errors.Join(errs...)
+}
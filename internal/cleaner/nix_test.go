@@ -0,0 +1,59 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNixGenerations(t *testing.T) {
+	dir := t.TempDir()
+	for _, n := range []int{1, 2, 3, 10} {
+		link := filepath.Join(dir, "profile-"+strconv.Itoa(n)+"-link")
+		if err := os.Symlink("/nix/store/xyz-profile", link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other profiles sharing the dir are not ours
+	if err := os.Symlink("/nix/store/abc-hm", filepath.Join(dir, "home-manager-1-link")); err != nil {
+		t.Fatal(err)
+	}
+	profile := filepath.Join(dir, "profile")
+	if err := os.Symlink("profile-3-link", profile); err != nil {
+		t.Fatal(err)
+	}
+
+	gens, current := nixGenerations(profile)
+	if current != 3 {
+		t.Errorf("current = %d, want 3", current)
+	}
+	var numbers []int
+	for _, g := range gens {
+		numbers = append(numbers, g.number)
+	}
+	if len(numbers) != 4 || numbers[0] != 10 || numbers[3] != 1 {
+		t.Errorf("generations = %v, want newest first", numbers)
+	}
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	tests := []struct {
+		i        int
+		date     time.Time
+		keepLast int
+		keepDays int
+		want     bool
+	}{
+		{1, old, 3, 0, true},
+		{3, old, 3, 0, false},
+		{3, time.Now(), 3, 7, true},
+		{3, old, 3, 7, false},
+		{0, old, 0, 7, false},
+	}
+	for _, tt := range tests {
+		if got := nixKeep(tt.i, tt.date, tt.keepLast, tt.keepDays); got != tt.want {
+			t.Errorf("nixKeep(%d, %v, %d, %d) = %v", tt.i, tt.date, tt.keepLast, tt.keepDays, got)
+		}
+	}
+}
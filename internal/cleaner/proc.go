@@ -0,0 +1,140 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProcessSnapshot is a point-in-time view of the running processes, built
+// from /proc/*/exe, cmdline and fd links. Cleaners use it to avoid deleting
+// files that are open or belong to an application that is running.
+type ProcessSnapshot struct {
+	// names holds executable base names and short command names
+	names    map[string]bool
+	cmdlines []string
+	// paths holds every open file, working directory and executable, sorted
+	paths []string
+}
+
+// SnapshotProcesses inspects /proc. Processes of other users are only
+// partially visible without root, which errs on the side of "not in use".
+func SnapshotProcesses() *ProcessSnapshot {
+	p := &ProcessSnapshot{names: make(map[string]bool)}
+	pids, _ := filepath.Glob("/proc/[0-9]*")
+
+	for _, pid := range pids {
+		if exe, err := os.Readlink(filepath.Join(pid, "exe")); err == nil {
+			exe = strings.TrimSuffix(exe, " (deleted)")
+			p.names[filepath.Base(exe)] = true
+			p.paths = append(p.paths, exe)
+		}
+		if comm, err := os.ReadFile(filepath.Join(pid, "comm")); err == nil {
+			p.names[strings.TrimSpace(string(comm))] = true
+		}
+		if data, err := os.ReadFile(filepath.Join(pid, "cmdline")); err == nil && len(data) > 0 {
+			p.cmdlines = append(p.cmdlines, strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " ")))
+		}
+		if cwd, err := os.Readlink(filepath.Join(pid, "cwd")); err == nil {
+			p.paths = append(p.paths, cwd)
+		}
+
+		fds, _ := os.ReadDir(filepath.Join(pid, "fd"))
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(pid, "fd", fd.Name()))
+			// Sockets, pipes and anon inodes are not paths
+			if err != nil || !strings.HasPrefix(target, "/") {
+				continue
+			}
+			p.paths = append(p.paths, strings.TrimSuffix(target, " (deleted)"))
+		}
+	}
+
+	sort.Strings(p.paths)
+	return p
+}
+
+// Running reports whether a process with any of the given executable names is
+// running. Names with a slash are full executable paths, for apps whose
+// channels share a binary name.
+func (p *ProcessSnapshot) Running(names ...string) bool {
+	for _, n := range names {
+		if strings.Contains(n, "/") {
+			i := sort.SearchStrings(p.paths, n)
+			if i < len(p.paths) && p.paths[i] == n {
+				return true
+			}
+			continue
+		}
+		if p.names[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// InUse reports whether path, or anything below it, is open, is the
+// working directory of a process or is a running executable
+func (p *ProcessSnapshot) InUse(path string) bool {
+	path = filepath.Clean(path)
+	i := sort.SearchStrings(p.paths, path)
+	if i < len(p.paths) && p.paths[i] == path {
+		return true
+	}
+	// Entries below path sort right after "path/"
+	prefix := path + string(os.PathSeparator)
+	j := sort.SearchStrings(p.paths, prefix)
+	return j < len(p.paths) && strings.HasPrefix(p.paths[j], prefix)
+}
+
+// Busy reports whether a process named like any of names is running or any
+// of dirs is in use
+func (p *ProcessSnapshot) Busy(names, dirs []string) bool {
+	if p.Running(names...) {
+		return true
+	}
+	for _, d := range dirs {
+		if p.InUse(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// idleTargets returns the targets Clean may delete now. The snapshot Scan
+// took is stale once the user has picked entries: an app started in between
+// must not lose its files, so a fresh snapshot is taken and the targets busy
+// reports for are skipped.
+func (d *detailSet) idleTargets(busy func(procs *ProcessSnapshot, t FileDetail) bool) []FileDetail {
+	procs := SnapshotProcesses()
+	var idle []FileDetail
+	for _, t := range d.targets() {
+		if !busy(procs, t) {
+			idle = append(idle, t)
+		}
+	}
+	return idle
+}
+
+// StartedFrom reports whether any process command line references a file below dir
+func (p *ProcessSnapshot) StartedFrom(dir string) bool {
+	prefix := filepath.Clean(dir) + string(os.PathSeparator)
+	for _, cmd := range p.cmdlines {
+		if strings.Contains(cmd, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mentions reports whether any process command line contains path, e.g. a
+// script or AppImage passed to an interpreter or runtime
+func (p *ProcessSnapshot) Mentions(path string) bool {
+	for _, cmd := range p.cmdlines {
+		if strings.Contains(cmd, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package cleaner
+
+import (
+	"testing"
+)
+
+func TestProcessSnapshotInUse(t *testing.T) {
+	p := &ProcessSnapshot{paths: []string{"/tmp/a/b.sock", "/tmp/ab", "/var/log/syslog"}}
+	tests := map[string]bool{
+		"/tmp/a":          true,
+		"/tmp/a/b.sock":   true,
+		"/tmp/ab":         true,
+		"/tmp/abc":        false,
+		"/tmp":            true,
+		"/var/log/syslog": true,
+		"/var/lib":        false,
+	}
+	for path, want := range tests {
+		if got := p.InUse(path); got != want {
+			t.Errorf("InUse(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestProcessSnapshotBusy(t *testing.T) {
+	p := &ProcessSnapshot{
+		names: map[string]bool{"slack": true},
+		paths: []string{"/opt/google/chrome/chrome", "/tmp/app/Cache/data_0"},
+	}
+	tests := []struct {
+		names, dirs []string
+		want        bool
+	}{
+		{[]string{"Slack", "slack"}, nil, true},
+		{[]string{"/opt/google/chrome/chrome"}, nil, true},
+		{[]string{"/opt/google/chrome-beta/chrome"}, []string{"/tmp/app/GPUCache"}, false},
+		{nil, []string{"/tmp/app/GPUCache", "/tmp/app/Cache"}, true},
+	}
+	for _, tt := range tests {
+		if got := p.Busy(tt.names, tt.dirs); got != tt.want {
+			t.Errorf("Busy(%v, %v) = %v, want %v", tt.names, tt.dirs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// remoteIDERoots are the per-user install dirs of VS Code style remote servers
+var remoteIDERoots = []struct {
+	name string
+	dir  string
+}{
+	{"VS Code Server", ".vscode-server"},
+	{"VS Code Server (Insiders)", ".vscode-server-insiders"},
+	{"Cursor Server", ".cursor-server"},
+	{"Windsurf Server", ".windsurf-server"},
+}
+
+// vsixPlatforms are the target platforms platform specific extensions append
+// to their dir name, as in "ms-python.python-2024.2.1-linux-x64"
+var vsixPlatforms = []string{
+	"linux-x64", "linux-arm64", "linux-armhf",
+	"alpine-x64", "alpine-arm64",
+	"darwin-x64", "darwin-arm64",
+	"win32-x64", "win32-arm64", "win32-ia32",
+	"web",
+}
+
+// vsixInstalled is the part of an extensions/extensions.json entry that says
+// which dir holds an installed extension
+type vsixInstalled struct {
+	RelativeLocation string `json:"relativeLocation"`
+	Location         struct {
+		Path string `json:"path"`
+	} `json:"location"`
+}
+
+// RemoteIDECleaner removes server builds and superseded extension versions
+// that VS Code style remote servers leave behind on dev boxes. Builds used by
+// a running process, and the most recently installed one, are kept.
+type RemoteIDECleaner struct {
+	detailSet
+}
+
+func (c *RemoteIDECleaner) Name() string {
+	return "Remote IDE Servers (VS Code, Cursor)"
+}
+
+func (c *RemoteIDECleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *RemoteIDECleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	procs := SnapshotProcesses()
+	c.found = nil
+	for _, r := range remoteIDERoots {
+		root := filepath.Join(home, r.dir)
+		if !dirExists(root) {
+			continue
+		}
+		c.scanServerBuilds(r.name, root, procs)
+		c.scanExtensions(r.name, filepath.Join(root, "extensions"), procs)
+	}
+	return totalSize(c.found), nil
+}
+
+// scanServerBuilds handles both the legacy bin/<commit> layout and the
+// cli/servers/Stable-<commit> layout of newer servers
+func (c *RemoteIDECleaner) scanServerBuilds(name, root string, procs *ProcessSnapshot) {
+	var builds []string
+	if entries, err := os.ReadDir(filepath.Join(root, "bin")); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				builds = append(builds, filepath.Join(root, "bin", e.Name()))
+			}
+		}
+	}
+	if entries, err := os.ReadDir(filepath.Join(root, "cli", "servers")); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				builds = append(builds, filepath.Join(root, "cli", "servers", e.Name()))
+			}
+		}
+	}
+
+	// Without a running server we keep the build installed last
+	var newest string
+	var newestTime time.Time
+	for _, b := range builds {
+		if info, err := os.Stat(b); err == nil && info.ModTime().After(newestTime) {
+			newest, newestTime = b, info.ModTime()
+		}
+	}
+
+	for _, b := range builds {
+		if b == newest || procs.StartedFrom(b) || procs.InUse(b) {
+			continue
+		}
+		size, _ := simpleDirScan(b)
+		commit := filepath.Base(b)
+		if i := strings.LastIndex(commit, "-"); i >= 0 {
+			commit = commit[i+1:]
+		}
+		if len(commit) > 10 {
+			commit = commit[:10]
+		}
+		c.found = append(c.found, FileDetail{
+			Path:     b,
+			Size:     size,
+			Label:    name + " build " + commit + " (stale)",
+			Selected: true,
+		})
+	}
+}
+
+// scanExtensions reports the extension dirs extensions.json no longer lists
+// and anything the server itself marked in .obsolete. Without extensions.json
+// every version older than the newest one installed is reported instead.
+func (c *RemoteIDECleaner) scanExtensions(name, dir string, procs *ProcessSnapshot) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	obsolete := make(map[string]bool)
+	if data, err := os.ReadFile(filepath.Join(dir, ".obsolete")); err == nil {
+		_ = json.Unmarshal(data, &obsolete)
+	}
+	installed := installedExtensions(dir)
+
+	type extVersion struct {
+		dir     string
+		version string
+	}
+	groups := make(map[string][]extVersion)
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if obsolete[e.Name()] {
+			c.addExtension(name, dir, e.Name(), "marked obsolete", procs)
+			continue
+		}
+		if installed != nil {
+			if !installed[e.Name()] {
+				c.addExtension(name, dir, e.Name(), "not installed", procs)
+			}
+			continue
+		}
+
+		// "publisher.ext-1.2.3", "publisher.ext-1.2.3-linux-x64" or
+		// "publisher.ext-1.0.0-beta.1"
+		id, version, ok := splitNameVersion(e.Name())
+		if !ok {
+			continue
+		}
+		key := id
+		for _, platform := range vsixPlatforms {
+			if core, ok := strings.CutSuffix(version, "-"+platform); ok {
+				key, version = id+"@"+platform, core
+				break
+			}
+		}
+		groups[key] = append(groups[key], extVersion{e.Name(), version})
+	}
+
+	for _, k := range sortedKeys(groups) {
+		versions := groups[k]
+		if len(versions) < 2 {
+			continue
+		}
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i].version, versions[j].version) > 0 })
+		for _, v := range versions[1:] {
+			c.addExtension(name, dir, v.dir, "superseded by "+versions[0].version, procs)
+		}
+	}
+}
+
+// installedExtensions returns the dir names extensions.json lists, or nil
+// when it is missing or cannot be parsed
+func installedExtensions(dir string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(dir, "extensions.json"))
+	if err != nil {
+		return nil
+	}
+	var list []vsixInstalled
+	if json.Unmarshal(data, &list) != nil {
+		return nil
+	}
+	installed := make(map[string]bool)
+	for _, e := range list {
+		if e.RelativeLocation != "" {
+			installed[e.RelativeLocation] = true
+		} else if e.Location.Path != "" {
+			installed[filepath.Base(e.Location.Path)] = true
+		}
+	}
+	return installed
+}
+
+func (c *RemoteIDECleaner) addExtension(name, dir, ext, reason string, procs *ProcessSnapshot) {
+	path := filepath.Join(dir, ext)
+	if procs.StartedFrom(path) || procs.InUse(path) {
+		return
+	}
+	size, _ := simpleDirScan(path)
+	c.found = append(c.found, FileDetail{
+		Path:     path,
+		Size:     size,
+		Label:    name + " extension " + ext + " (" + reason + ")",
+		Selected: true,
+	})
+}
+
+func (c *RemoteIDECleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
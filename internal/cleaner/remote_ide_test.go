@@ -0,0 +1,49 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoteIDEScan(t *testing.T) {
+	runScanCases(t, func() DetailCleaner { return &RemoteIDECleaner{} }, []scanCase{
+		{
+			name: "vscode server",
+			files: map[string]string{
+				".vscode-server/bin/0123456789abcdef/node":                        "old",
+				".vscode-server/bin/fedcba9876543210/node":                        "new",
+				".vscode-server/extensions/extensions.json":                       `[{"identifier":{"id":"ms-python.python"},"version":"2024.2.1","relativeLocation":"ms-python.python-2024.2.1-linux-x64"},{"identifier":{"id":"golang.go"},"version":"0.40.0","location":{"path":"/home/u/.vscode-server/extensions/golang.go-0.40.0"}}]`,
+				".vscode-server/extensions/ms-python.python-2024.2.1-linux-x64/a": "x",
+				".vscode-server/extensions/ms-python.python-2024.0.0-linux-x64/a": "x",
+				".vscode-server/extensions/golang.go-0.40.0/a":                    "x",
+				".vscode-server/extensions/golang.go-0.41.0/a":                    "x",
+			},
+			setup: func(t *testing.T, home string) {
+				old := time.Now().Add(-48 * time.Hour)
+				if err := os.Chtimes(filepath.Join(home, ".vscode-server/bin/0123456789abcdef"), old, old); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]bool{
+				".vscode-server/bin/0123456789abcdef":                           true,
+				".vscode-server/extensions/golang.go-0.41.0":                    true,
+				".vscode-server/extensions/ms-python.python-2024.0.0-linux-x64": true,
+			},
+		},
+		{
+			// Without extensions.json versions are compared per platform
+			name: "no extensions.json",
+			files: map[string]string{
+				".cursor-server/extensions/acme.tool-1.0.0-beta.1/a":     "x",
+				".cursor-server/extensions/acme.tool-1.0.0-beta.2/a":     "x",
+				".cursor-server/extensions/acme.bin-2.0.0-linux-x64/a":   "x",
+				".cursor-server/extensions/acme.bin-1.0.0-linux-arm64/a": "x",
+			},
+			want: map[string]bool{
+				".cursor-server/extensions/acme.tool-1.0.0-beta.1": true,
+			},
+		},
+	})
+}
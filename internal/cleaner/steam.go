@@ -0,0 +1,266 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// steamMaxAppID is the highest real Steam app ID; Proton prefixes of
+// non-Steam shortcuts use IDs above it and have no manifest
+const steamMaxAppID = 1<<31 - 1
+
+// gpuShaderCacheDirs, relative to home, are the shader caches of the GPU
+// drivers. They are rebuilt on demand, at the cost of stutter in games.
+var gpuShaderCacheDirs = []struct {
+	path  string
+	label string
+}{
+	{".cache/mesa_shader_cache", "Mesa shader cache"},
+	{".cache/mesa_shader_cache_db", "Mesa shader cache (db)"},
+	{".cache/radv_builtin_shaders", "RADV built-in shaders"},
+	{".nv/GLCache", "NVIDIA GL shader cache"},
+	{".nv/ComputeCache", "NVIDIA compute cache"},
+	{".cache/nvidia/GLCache", "NVIDIA GL shader cache"},
+	{".cache/nvidia/ComputeCache", "NVIDIA compute cache"},
+}
+
+// SteamCleaner finds the shader caches and Proton prefixes (compatdata) in
+// every Steam library that belong to games which are no longer installed,
+// going by libraryfolders.vdf and the appmanifest_*.acf files. Orphaned
+// shader caches are preselected; prefixes may hold save games, so they are
+// not. The GPU driver shader caches are listed as separate items.
+type SteamCleaner struct {
+	detailSet
+}
+
+func (c *SteamCleaner) Name() string {
+	return "Steam, Proton & Shader Caches"
+}
+
+func (c *SteamCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *SteamCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	procs := SnapshotProcesses()
+	c.found = nil
+	libraries, installed := steamLibraries(home)
+	for _, lib := range libraries {
+		c.scanLibrary(lib, installed, procs)
+	}
+
+	for _, d := range gpuShaderCacheDirs {
+		path := filepath.Join(home, d.path)
+		size, _ := simpleDirScan(path)
+		if size == 0 {
+			continue
+		}
+		detail := FileDetail{Path: path, Size: size, Label: d.label + " (~/" + d.path + ")"}
+		if procs.InUse(path) {
+			detail.Warning = "in use"
+		}
+		c.found = append(c.found, detail)
+	}
+	return totalSize(c.found), nil
+}
+
+// steamLibraries returns the steamapps dirs of every Steam install and its
+// libraries, and the IDs of the installed apps. Apps libraryfolders.vdf lists
+// count as installed too, so games on an unmounted drive are safe.
+func steamLibraries(home string) ([]string, map[string]bool) {
+	seen := make(map[string]bool)
+	installed := make(map[string]bool)
+	var libraries []string
+	addLibrary := func(dir string) {
+		steamapps := filepath.Join(dir, "steamapps")
+		if real, err := filepath.EvalSymlinks(steamapps); err == nil {
+			steamapps = real
+		}
+		if seen[steamapps] || !dirExists(steamapps) {
+			return
+		}
+		seen[steamapps] = true
+		libraries = append(libraries, steamapps)
+	}
+
+	for _, root := range []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	} {
+		data, err := os.ReadFile(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			continue
+		}
+		addLibrary(root)
+		folders := parseVDF(string(data)).child("libraryfolders")
+		if folders == nil {
+			continue
+		}
+		for _, key := range sortedKeys(folders.children) {
+			folder := folders.children[key]
+			addLibrary(folder.values["path"])
+			if apps := folder.child("apps"); apps != nil {
+				for id := range apps.values {
+					installed[id] = true
+				}
+			}
+		}
+		// Before 2021 entries were plain "1" "/path/to/library" pairs
+		for key, path := range folders.values {
+			if _, err := strconv.Atoi(key); err == nil {
+				addLibrary(path)
+			}
+		}
+	}
+
+	for _, lib := range libraries {
+		manifests, _ := filepath.Glob(filepath.Join(lib, "appmanifest_*.acf"))
+		for _, m := range manifests {
+			id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "appmanifest_"), ".acf")
+			if data, err := os.ReadFile(m); err == nil {
+				if state := parseVDF(string(data)).child("AppState"); state != nil && state.values["appid"] != "" {
+					id = state.values["appid"]
+				}
+			}
+			installed[id] = true
+		}
+	}
+	return libraries, installed
+}
+
+// scanLibrary lists the shadercache and compatdata dirs of uninstalled apps
+func (c *SteamCleaner) scanLibrary(steamapps string, installed map[string]bool, procs *ProcessSnapshot) {
+	for _, kind := range []string{"shadercache", "compatdata"} {
+		entries, err := os.ReadDir(filepath.Join(steamapps, kind))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			id, err := strconv.ParseInt(e.Name(), 10, 64)
+			if err != nil || !e.IsDir() || id <= 0 || id > steamMaxAppID || installed[e.Name()] {
+				continue
+			}
+			path := filepath.Join(steamapps, kind, e.Name())
+			size, _ := simpleDirScan(path)
+			if size == 0 {
+				continue
+			}
+
+			detail := FileDetail{Path: path, Size: size, Selected: true}
+			if kind == "shadercache" {
+				detail.Label = "Shader cache of uninstalled app " + e.Name() + " (" + steamapps + ")"
+			} else {
+				detail.Label = "Proton prefix of uninstalled app " + e.Name() + " (" + steamapps + ")"
+				detail.Warning = "may contain save games"
+				detail.Selected = false
+			}
+			if procs.InUse(path) {
+				detail.Warning = "in use"
+				detail.Selected = false
+			}
+			c.found = append(c.found, detail)
+		}
+	}
+}
+
+// vdfNode is a section of Valve's KeyValues text format. Keys are case
+// insensitive in Steam's files but are kept as written.
+type vdfNode struct {
+	values   map[string]string
+	children map[string]*vdfNode
+}
+
+// child returns the section named key, compared case insensitively
+func (n *vdfNode) child(key string) *vdfNode {
+	if c, ok := n.children[key]; ok {
+		return c
+	}
+	for k, c := range n.children {
+		if strings.EqualFold(k, key) {
+			return c
+		}
+	}
+	return nil
+}
+
+// parseVDF parses the KeyValues text format used by libraryfolders.vdf and
+// appmanifest files: quoted keys followed by a quoted value or a { } section.
+// Malformed input yields whatever was parsed up to that point.
+func parseVDF(data string) *vdfNode {
+	tokens := vdfTokens(data)
+	root, _ := parseVDFSection(tokens, 0)
+	return root
+}
+
+// parseVDFSection parses key/value pairs from tokens[i:] up to the closing
+// brace and returns the section and the index after it
+func parseVDFSection(tokens []string, i int) (*vdfNode, int) {
+	n := &vdfNode{values: make(map[string]string), children: make(map[string]*vdfNode)}
+	for i < len(tokens) {
+		key := tokens[i]
+		if key == "}" {
+			return n, i + 1
+		}
+		if i+1 >= len(tokens) {
+			break
+		}
+		if tokens[i+1] == "{" {
+			n.children[key], i = parseVDFSection(tokens, i+2)
+			continue
+		}
+		n.values[key] = tokens[i+1]
+		i += 2
+	}
+	return n, i
+}
+
+// vdfTokens splits KeyValues text into quoted strings, without their quotes,
+// and braces. // comments and unquoted text such as [$WIN32] conditionals
+// are skipped.
+func vdfTokens(data string) []string {
+	var tokens []string
+	for i := 0; i < len(data); i++ {
+		switch ch := data[i]; {
+		case ch == '{' || ch == '}':
+			tokens = append(tokens, string(ch))
+		case ch == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			var sb strings.Builder
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+					switch data[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(data[i])
+					}
+					continue
+				}
+				sb.WriteByte(data[i])
+			}
+			tokens = append(tokens, sb.String())
+		}
+	}
+	return tokens
+}
+
+func (c *SteamCleaner) Clean() error {
+	for _, t := range c.targets() {
+		_ = os.RemoveAll(t.Path)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSteamOrphans(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, ".local", "share", "Steam")
+	library := filepath.Join(home, "Games", "SteamLibrary")
+	libraryfolders := `"libraryfolders"
+{
+	"0"
+	{
+		"path"		"` + root + `"
+		"apps"
+		{
+			"620"		"12345"
+		}
+	}
+	"1"
+	{
+		"path"		"` + library + `"
+		// comment
+		"apps"
+		{
+			"730"		"4567"
+		}
+	}
+}`
+	files := map[string]string{
+		"steamapps/libraryfolders.vdf":          libraryfolders,
+		"steamapps/appmanifest_620.acf":         "\"AppState\"\n{\n\t\"appid\"\t\t\"620\"\n\t\"name\"\t\t\"Portal 2\"\n}",
+		"steamapps/shadercache/620/cache":       "x",
+		"steamapps/shadercache/440/cache":       "x",
+		"steamapps/compatdata/440/pfx/user.reg": "x",
+		"steamapps/compatdata/3141592653/pfx":   "x",
+	}
+	writeTestFiles(t, root, files)
+	// 730 lives on a library whose drive is not mounted
+	writeTestFiles(t, library, map[string]string{"steamapps/shadercache/730/cache": "x"})
+
+	libraries, installed := steamLibraries(home)
+	if len(libraries) != 2 || !installed["620"] || !installed["730"] || installed["440"] {
+		t.Fatalf("steamLibraries() = %v, %v", libraries, installed)
+	}
+
+	c := &SteamCleaner{}
+	for _, lib := range libraries {
+		c.scanLibrary(lib, installed, SnapshotProcesses())
+	}
+	want := map[string]bool{
+		filepath.Join(root, "steamapps", "shadercache", "440"): true,
+		filepath.Join(root, "steamapps", "compatdata", "440"):  false,
+	}
+	if len(c.found) != len(want) {
+		t.Fatalf("found %v", c.found)
+	}
+	for _, d := range c.found {
+		if selected, ok := want[d.Path]; !ok || d.Selected != selected {
+			t.Errorf("%s selected = %v", d.Path, d.Selected)
+		}
+	}
+}
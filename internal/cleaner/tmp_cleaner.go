@@ -1,10 +1,45 @@
 package cleaner
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
+// tmpfilesDirs are read in order; a file in a later dir overrides a file
+// with the same name in an earlier one, like systemd-tmpfiles does
+var tmpfilesDirs = []string{"/usr/lib/tmpfiles.d", "/run/tmpfiles.d", "/etc/tmpfiles.d"}
+
+// tmpDefaultAges apply when no tmpfiles.d line mentions a dir at all. They
+// match systemd's upstream defaults. A line without an age, like the
+// "D /tmp 1777 root root -" of distributions that clear /tmp at boot only,
+// means nothing is aged out there.
+var tmpDefaultAges = map[string]time.Duration{
+	"/tmp":     10 * 24 * time.Hour,
+	"/var/tmp": 30 * 24 * time.Hour,
+}
+
+// tmpfilesRule is one parsed tmpfiles.d line that matters for cleaning
+type tmpfilesRule struct {
+	typ  byte
+	path string
+	age  time.Duration
+	// ageBy holds the timestamps to compare ("acm" for files, "ACM" for
+	// dirs); empty means all of them
+	ageBy string
+	// shallow ("~" prefix) protects the entries directly inside path
+	shallow bool
+}
+
+// TmpCleaner ages out old files in /tmp and /var/tmp following
+// systemd-tmpfiles semantics: ages come from tmpfiles.d, an entry is old when
+// its newest atime/mtime/ctime (mtime/ctime for dirs) is past the age,
+// "x"/"X" lines exclude paths, and without root only our own files are
+// touched (the dirs are sticky).
 type TmpCleaner struct{}
 
 func (c *TmpCleaner) Name() string {
@@ -12,55 +47,325 @@ func (c *TmpCleaner) Name() string {
 }
 
 func (c *TmpCleaner) RequiresRoot() bool {
-	return true
+	// Without root we still clean our own files
+	return false
 }
 
-func (c *TmpCleaner) Scan() (int64, error) {
-	paths := []string{"/tmp", "/var/tmp", "/var/crash"}
-	var size int64
+// tmpBootIDFile holds the boot ID the %b specifier expands to
+var tmpBootIDFile = "/proc/sys/kernel/random/boot_id"
 
-	for _, p := range paths {
-		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip permission denied errors
+// loadTmpfilesRules returns the dir rules, with or without an age, and the
+// x/X exclusion rules
+func loadTmpfilesRules(dirs []string) (ages, excludes []tmpfilesRule) {
+	files := make(map[string]string) // base name -> path
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+		for _, m := range matches {
+			files[filepath.Base(m)] = m
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		f, err := os.Open(files[name])
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			rule, ok := parseTmpfilesLine(scanner.Text())
+			if !ok {
+				continue
 			}
-			if !info.IsDir() {
-				// Avoid socket and device files
-				if info.Mode().IsRegular() {
-					size += info.Size()
-				}
+			switch rule.typ {
+			case 'x', 'X':
+				excludes = append(excludes, rule)
+			case 'd', 'D', 'e', 'v', 'q', 'Q':
+				ages = append(ages, rule)
 			}
-			return nil
-		})
+		}
+		f.Close()
+	}
+	return ages, excludes
+}
+
+// parseTmpfilesLine parses "Type Path Mode User Group Age Argument"
+func parseTmpfilesLine(line string) (tmpfilesRule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return tmpfilesRule{}, false
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return tmpfilesRule{}, false
+	}
+	typ := fields[0][0]
+	path, ok := expandTmpfilesSpecifiers(fields[1], typ == 'x' || typ == 'X')
+	if !ok {
+		return tmpfilesRule{}, false
+	}
+
+	rule := tmpfilesRule{typ: typ, path: filepath.Clean(path)}
+	if len(fields) >= 6 && fields[5] != "-" {
+		age := fields[5]
+		if strings.HasPrefix(age, "~") {
+			rule.shallow = true
+			age = age[1:]
+		}
+		if by, rest, ok := strings.Cut(age, ":"); ok {
+			rule.ageBy, age = by, rest
+		}
+		rule.age = parseTmpfilesAge(age)
+	}
+	return rule, true
+}
+
+// expandTmpfilesSpecifiers expands %b, the boot ID that names the
+// systemd-private-* dirs, and %%. Other specifiers (%h, %U...) depend on the
+// user the line is applied for: they match anything in exclusions, so those
+// exclude too much rather than too little, and drop other lines.
+func expandTmpfilesSpecifiers(path string, exclude bool) (string, bool) {
+	if !strings.Contains(path, "%") {
+		return path, true
+	}
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '%' || i+1 == len(path) {
+			sb.WriteByte(path[i])
+			continue
+		}
+		i++
+		switch spec := path[i]; {
+		case spec == '%':
+			sb.WriteByte('%')
+		case spec == 'b' && tmpBootID() != "":
+			sb.WriteString(tmpBootID())
+		case exclude:
+			sb.WriteByte('*')
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// tmpBootID returns the boot ID as systemd formats it, without dashes
+func tmpBootID() string {
+	data, err := os.ReadFile(tmpBootIDFile)
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(strings.TrimSpace(string(data)), "-", "")
+}
+
+// parseTmpfilesAge parses systemd time spans such as "10d", "1w" or "1d12h"
+func parseTmpfilesAge(s string) time.Duration {
+	units := map[string]time.Duration{
+		"us": time.Microsecond, "ms": time.Millisecond,
+		"s": time.Second, "sec": time.Second,
+		"m": time.Minute, "min": time.Minute,
+		"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+		"M": 30 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		n, err := strconv.Atoi(s[:i])
 		if err != nil {
+			return 0
+		}
+		unit := strings.TrimSpace(s[i:j])
+		if unit == "" {
+			unit = "s"
+		}
+		mult, ok := units[unit]
+		if !ok {
+			return 0
+		}
+		total += time.Duration(n) * mult
+		s = s[j:]
+	}
+	return total
+}
+
+// tmpSweep holds what one Scan or Clean pass needs
+type tmpSweep struct {
+	// rules maps a dir to the age rule configured for it
+	rules    map[string]tmpfilesRule
+	excludes []tmpfilesRule
+	procs    *ProcessSnapshot
+	uid      int
+	root     bool
+	dryRun   bool
+}
+
+// excluded reports whether path matches an x (subtree) or X (entry only) line
+func (s *tmpSweep) excluded(path string) (entry, subtree bool) {
+	for _, r := range s.excludes {
+		if ok, _ := filepath.Match(r.path, path); ok {
+			if r.typ == 'x' {
+				return true, true
+			}
+			entry = true
+		}
+	}
+	return entry, false
+}
+
+// ruleFor returns the age rule configured for dir, whose path may be a glob
+func (s *tmpSweep) ruleFor(dir string) (tmpfilesRule, bool) {
+	if r, ok := s.rules[dir]; ok {
+		return r, true
+	}
+	for _, pattern := range sortedKeys(s.rules) {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return s.rules[pattern], true
+		}
+	}
+	return tmpfilesRule{}, false
+}
+
+// isOld compares the newest of the selected timestamps against the rule's
+// age. Dir atimes are ignored, like systemd-tmpfiles does: reading a dir,
+// as every scan does, updates it.
+func isOld(info os.FileInfo, rule tmpfilesRule) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Since(info.ModTime()) > rule.age
+	}
+
+	letters := "acm"
+	if info.IsDir() {
+		letters = "ACM"
+	}
+	use := func(l byte) bool {
+		return rule.ageBy == "" || strings.IndexByte(rule.ageBy, l) >= 0
+	}
+
+	var newest time.Time
+	stamps := []time.Time{
+		time.Unix(st.Atim.Sec, st.Atim.Nsec),
+		time.Unix(st.Ctim.Sec, st.Ctim.Nsec),
+		time.Unix(st.Mtim.Sec, st.Mtim.Nsec),
+	}
+	for i, t := range stamps {
+		if letters[i] == 'A' {
 			continue
 		}
+		if use(letters[i]) && t.After(newest) {
+			newest = t
+		}
 	}
-	return size, nil
+	return !newest.IsZero() && time.Since(newest) > rule.age
 }
 
-func (c *TmpCleaner) Clean() error {
-	paths := []string{"/tmp", "/var/tmp", "/var/crash"}
+// sweep ages out entries below dir and returns the bytes freed (or, in dry
+// run mode, that would be freed)
+func (s *tmpSweep) sweep(dir string, rule tmpfilesRule, depth int) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
 
-	for _, p := range paths {
-		entries, err := os.ReadDir(p)
+	var size int64
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		// X11 sockets and locks directly in /tmp, e.g. .X11-unix
+		if dir == "/tmp" && strings.HasPrefix(e.Name(), ".X") {
+			continue
+		}
+		skipEntry, skipTree := s.excluded(path)
+		if skipTree || s.procs.InUse(path) {
+			continue
+		}
+
+		info, err := os.Lstat(path)
 		if err != nil {
-			continue // Skip if we can't read dir
+			continue
+		}
+		// The dirs are sticky: without root only our own entries can go
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && !s.root && int(st.Uid) != s.uid {
+			continue
 		}
-		for _, entry := range entries {
-			// Do not remove important system lock files or X11 socket dirs if possible,
-			// though typical `/tmp` wipes just blind delete. `os.RemoveAll` will fail
-			// on items actively locked by the OS, which is generally safe enough.
+		protected := skipEntry || (rule.shallow && depth == 0)
 
-			// Be slightly cautious, skip hidden files starting with .X directly in /tmp,
-			// e.g. .X11-unix
-			if p == "/tmp" && len(entry.Name()) > 1 && entry.Name()[0:2] == ".X" {
-				continue
+		if info.IsDir() {
+			old := isOld(info, rule)
+			// A subdir with its own tmpfiles.d line is aged by that line,
+			// or not at all when it has no age
+			if r, ok := s.ruleFor(path); ok {
+				if r.age > 0 {
+					size += s.sweep(path, r, 0)
+				}
+			} else {
+				size += s.sweep(path, rule, depth+1)
+			}
+			if !protected && old && !s.dryRun {
+				_ = os.Remove(path) // only succeeds once empty
 			}
+			continue
+		}
+
+		// Leave sockets, FIFOs and device nodes alone
+		if protected || (!info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0) {
+			continue
+		}
+		if isOld(info, rule) {
+			if s.dryRun {
+				size += info.Size()
+			} else if os.Remove(path) == nil {
+				size += info.Size()
+			}
+		}
+	}
+	return size
+}
 
-			fullPath := filepath.Join(p, entry.Name())
-			_ = os.RemoveAll(fullPath) // Ignore errors (like permission denied or in-use)
+// run sweeps every temp dir with the age rule that applies to it
+func (c *TmpCleaner) run(dryRun bool) int64 {
+	ages, excludes := loadTmpfilesRules(tmpfilesDirs)
+	s := &tmpSweep{
+		rules:    make(map[string]tmpfilesRule),
+		excludes: excludes,
+		procs:    SnapshotProcesses(),
+		uid:      os.Getuid(),
+		root:     os.Geteuid() == 0,
+		dryRun:   dryRun,
+	}
+
+	// The first line for a path wins, as in systemd-tmpfiles
+	for _, r := range ages {
+		if _, ok := s.rules[r.path]; !ok {
+			s.rules[r.path] = r
+		}
+	}
+
+	var size int64
+	for _, dir := range sortedKeys(tmpDefaultAges) {
+		rule, ok := s.rules[dir]
+		if !ok {
+			rule = tmpfilesRule{typ: 'd', path: dir, age: tmpDefaultAges[dir]}
+		}
+		if rule.age > 0 {
+			size += s.sweep(dir, rule, 0)
 		}
 	}
+	return size
+}
+
+func (c *TmpCleaner) Scan() (int64, error) {
+	return c.run(true), nil
+}
+
+func (c *TmpCleaner) Clean() error {
+	c.run(false)
 	return nil
 }
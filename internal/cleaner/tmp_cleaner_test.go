@@ -0,0 +1,60 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTmpfilesLine(t *testing.T) {
+	bootID := filepath.Join(t.TempDir(), "boot_id")
+	if err := os.WriteFile(bootID, []byte("0123abcd-4567-89ef-0123-456789abcdef\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { tmpBootIDFile = old }(tmpBootIDFile)
+	tmpBootIDFile = bootID
+
+	tests := []struct {
+		line    string
+		ok      bool
+		typ     byte
+		path    string
+		age     time.Duration
+		ageBy   string
+		shallow bool
+	}{
+		{"# comment", false, 0, "", 0, "", false},
+		{"D /tmp 1777 root root -", true, 'D', "/tmp", 0, "", false},
+		{"q /var/tmp 1777 root root 30d", true, 'q', "/var/tmp", 30 * 24 * time.Hour, "", false},
+		{"d /tmp/foo 0755 - - ~1d12h", true, 'd', "/tmp/foo", 36 * time.Hour, "", true},
+		{"e /tmp/bar - - - cmA:1w", true, 'e', "/tmp/bar", 7 * 24 * time.Hour, "cmA", false},
+		{"x /tmp/systemd-private-%b-*", true, 'x', "/tmp/systemd-private-0123abcd456789ef0123456789abcdef-*", 0, "", false},
+		{"X /tmp/systemd-private-%b-*/tmp", true, 'X', "/tmp/systemd-private-0123abcd456789ef0123456789abcdef-*/tmp", 0, "", false},
+		{"x /tmp/%u-cache", true, 'x', "/tmp/*-cache", 0, "", false},
+		{"d %h/.cache 0700 - - 30d", false, 0, "", 0, "", false},
+	}
+	for _, tt := range tests {
+		r, ok := parseTmpfilesLine(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseTmpfilesLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if ok && (r.typ != tt.typ || r.path != tt.path || r.age != tt.age || r.ageBy != tt.ageBy || r.shallow != tt.shallow) {
+			t.Errorf("parseTmpfilesLine(%q) = %+v", tt.line, r)
+		}
+	}
+}
+
+func TestTmpSweepRuleFor(t *testing.T) {
+	s := &tmpSweep{rules: map[string]tmpfilesRule{
+		"/tmp":         {typ: 'D', path: "/tmp"},
+		"/tmp/build-*": {typ: 'd', path: "/tmp/build-*", age: time.Hour},
+	}}
+	if r, ok := s.ruleFor("/tmp/build-42"); !ok || r.age != time.Hour {
+		t.Errorf("ruleFor(/tmp/build-42) = %+v, %v", r, ok)
+	}
+	if _, ok := s.ruleFor("/tmp/other"); ok {
+		t.Error("ruleFor(/tmp/other) matched")
+	}
+}
@@ -1,11 +1,26 @@
 package cleaner
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
-type UserCacheCleaner struct{}
+// UserCacheCleaner clears ~/.cache/thumbnails. With OrphansOnly it follows
+// the freedesktop thumbnail spec instead and only removes thumbnails whose
+// source file is gone or has changed since, plus the failure markers in fail/.
+type UserCacheCleaner struct {
+	OrphansOnly bool
+
+	orphans []string
+}
 
 func (c *UserCacheCleaner) Name() string {
 	return "User Cache (Thumbnails)"
@@ -20,38 +35,132 @@ func (c *UserCacheCleaner) Scan() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	
-	// Target just thumbnails for safety MVP
-	targets := []string{
-		filepath.Join(home, ".cache", "thumbnails"),
+	root := filepath.Join(home, ".cache", "thumbnails")
+
+	if !c.OrphansOnly {
+		// Target just thumbnails for safety MVP
+		return simpleDirScan(root)
 	}
-	
+
+	c.orphans = nil
 	var size int64
-	for _, t := range targets {
-		filepath.Walk(t, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if filepath.Dir(filepath.Dir(rel)) == "fail" || isOrphanThumbnail(path) {
+			c.orphans = append(c.orphans, path)
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, nil
+}
+
+// isOrphanThumbnail reports whether the source file recorded in the
+// thumbnail's Thumb::URI is gone or its mtime no longer matches Thumb::MTime.
+// Thumbnails of non-local URIs are kept as we can't check them.
+func isOrphanThumbnail(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	text, err := pngTextChunks(f)
+	if err != nil || text["Thumb::URI"] == "" {
+		return false
+	}
+	u, err := url.Parse(text["Thumb::URI"])
+	if err != nil || u.Scheme != "file" {
+		return false
+	}
+
+	info, err := os.Stat(u.Path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	mtime, err := strconv.ParseInt(text["Thumb::MTime"], 10, 64)
+	return err == nil && mtime != info.ModTime().Unix()
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMaxTextChunk bounds the tEXt chunks read; thumbnail metadata is just a
+// URI, a size and a time. Larger ones are skipped unread.
+const pngMaxTextChunk = 8 << 10
+
+// pngTextChunks returns the keyword/text pairs of the tEXt chunks of a PNG
+// that come before the image data. image/png skips ancillary chunks, so the
+// chunks are walked directly; chunks failing their CRC are ignored.
+func pngTextChunks(r io.ReadSeeker) (map[string]string, error) {
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(sig, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	text := make(map[string]string)
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return text, nil
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > math.MaxInt32 {
+			return text, errors.New("invalid PNG chunk length")
+		}
+		switch string(header[4:]) {
+		case "tEXt":
+			if length > pngMaxTextChunk {
+				break
 			}
-			if !info.IsDir() {
-				size += info.Size()
+			// The chunk data followed by its CRC, which covers type and data
+			data := make([]byte, length+4)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return text, nil
 			}
-			return nil
-		})
+			crc := crc32.NewIEEE()
+			crc.Write(header[4:])
+			crc.Write(data[:length])
+			if crc.Sum32() != binary.BigEndian.Uint32(data[length:]) {
+				continue
+			}
+			if key, value, ok := bytes.Cut(data[:length], []byte{0}); ok {
+				text[string(key)] = string(value)
+			}
+			continue
+		case "IDAT", "IEND":
+			// Thumbnailers write their metadata before the image data
+			return text, nil
+		}
+		// Skip the chunk data and the CRC
+		if _, err := r.Seek(int64(length)+4, io.SeekCurrent); err != nil {
+			return text, nil
+		}
 	}
-	
-	return size, nil
 }
 
 func (c *UserCacheCleaner) Clean() error {
+	if c.OrphansOnly {
+		for _, p := range c.orphans {
+			_ = os.Remove(p)
+		}
+		return nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return err
 	}
-	
+
 	targets := []string{
 		filepath.Join(home, ".cache", "thumbnails"),
 	}
-	
+
 	for _, t := range targets {
 		if err := os.RemoveAll(t); err != nil {
 			return err
@@ -0,0 +1,120 @@
+package cleaner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writePNGChunk appends a chunk with a valid CRC to buf
+func writePNGChunk(buf *bytes.Buffer, typ, data string) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(typ + data)
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE([]byte(typ+data)))
+}
+
+func TestIsOrphanThumbnail(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "photo one.jpg")
+	if err := os.WriteFile(src, []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	thumb := func(uri string, mtime int64) string {
+		var buf bytes.Buffer
+		buf.Write(pngSignature)
+		writePNGChunk(&buf, "tEXt", "Thumb::URI\x00"+uri)
+		writePNGChunk(&buf, "tEXt", "Thumb::MTime\x00"+strconv.FormatInt(mtime, 10))
+		writePNGChunk(&buf, "IEND", "")
+
+		n++
+		path := filepath.Join(dir, strconv.Itoa(n)+".png")
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	uri := "file://" + strings.ReplaceAll(src, " ", "%20")
+	mtime := info.ModTime().Unix()
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"current", thumb(uri, mtime), false},
+		{"changed", thumb(uri, mtime-60), true},
+		{"gone", thumb("file://"+filepath.Join(dir, "missing.jpg"), mtime), true},
+		{"remote", thumb("https://example.com/a.jpg", mtime+1), false},
+	}
+	for _, tt := range tests {
+		if got := isOrphanThumbnail(tt.path); got != tt.want {
+			t.Errorf("%s: isOrphanThumbnail = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPNGTextChunks(t *testing.T) {
+	png := func(build func(buf *bytes.Buffer)) *bytes.Reader {
+		var buf bytes.Buffer
+		buf.Write(pngSignature)
+		build(&buf)
+		return bytes.NewReader(buf.Bytes())
+	}
+	tests := []struct {
+		name    string
+		png     *bytes.Reader
+		want    map[string]string
+		wantErr bool
+	}{
+		{"valid", png(func(buf *bytes.Buffer) {
+			writePNGChunk(buf, "tEXt", "Thumb::MTime\x0042")
+			writePNGChunk(buf, "IEND", "")
+		}), map[string]string{"Thumb::MTime": "42"}, false},
+		{"bad CRC", png(func(buf *bytes.Buffer) {
+			buf.Write([]byte{0, 0, 0, 4})
+			buf.WriteString("tEXta\x00bcCRC!")
+			writePNGChunk(buf, "tEXt", "Thumb::MTime\x0042")
+		}), map[string]string{"Thumb::MTime": "42"}, false},
+		{"after image data", png(func(buf *bytes.Buffer) {
+			writePNGChunk(buf, "IDAT", "pixels")
+			writePNGChunk(buf, "tEXt", "Thumb::MTime\x0042")
+		}), map[string]string{}, false},
+		{"truncated", png(func(buf *bytes.Buffer) {
+			buf.Write([]byte{0, 0, 0, 100})
+			buf.WriteString("tEXtThumb::URI\x00file:")
+		}), map[string]string{}, false},
+		{"oversized", png(func(buf *bytes.Buffer) {
+			buf.Write([]byte{0x7f, 0xff, 0xff, 0xf0})
+			buf.WriteString("tEXtThumb::URI\x00file:")
+		}), map[string]string{}, false},
+		{"invalid length", png(func(buf *bytes.Buffer) {
+			buf.Write([]byte{0xff, 0xff, 0xff, 0xff})
+			buf.WriteString("tEXt")
+		}), map[string]string{}, true},
+	}
+	for _, tt := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		got, err := pngTextChunks(tt.png)
+		runtime.ReadMemStats(&after)
+		if (err != nil) != tt.wantErr || len(got) != len(tt.want) || got["Thumb::MTime"] != tt.want["Thumb::MTime"] {
+			t.Errorf("%s: pngTextChunks() = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+		// Chunk lengths come from the file and must not size allocations
+		if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+			t.Errorf("%s: pngTextChunks allocated %d bytes", tt.name, n)
+		}
+	}
+}
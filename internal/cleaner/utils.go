@@ -1,8 +1,17 @@
 package cleaner
 
 import (
+	"bufio"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // simpleDirScan walks a directory and returns its total size
@@ -19,3 +28,302 @@ func simpleDirScan(path string) (int64, error) {
 	})
 	return size, err
 }
+
+// dirExists reports whether path is a directory, following symlinks
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// semverPrefixRe matches strings starting with a "major.minor.patch" version
+var semverPrefixRe = regexp.MustCompile(`^\d+\.\d+\.\d+`)
+
+// splitNameVersion splits "name-1.2.3" into "name" and "1.2.3". Names may
+// contain dashes and digits ("sha-1-0.10.1"), so the first dash followed by
+// a full major.minor.patch version is taken as the separator.
+func splitNameVersion(s string) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '-' && semverPrefixRe.MatchString(s[i+1:]) {
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// compareVersions compares two dotted version strings such as "1.10.2" or
+// "2023.3". Numeric segments are compared as numbers, anything else
+// lexically. A pre-release suffix ("1.0.0-beta") sorts before the release.
+// It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	// Build metadata never affects precedence
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+
+	aCore, aPre, aHasPre := strings.Cut(a, "-")
+	bCore, bPre, bHasPre := strings.Cut(b, "-")
+
+	if c := compareSegments(aCore, bCore); c != 0 {
+		return c
+	}
+	switch {
+	case aHasPre && !bHasPre:
+		return -1
+	case !aHasPre && bHasPre:
+		return 1
+	}
+	return compareSegments(aPre, bPre)
+}
+
+func compareSegments(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+		an, aErr := strconv.ParseInt(as[i], 10, 64)
+		bn, bErr := strconv.ParseInt(bs[i], 10, 64)
+		if aErr == nil && bErr == nil {
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// detailSet implements the DetailCleaner bookkeeping for cleaners that
+// embed it: the entries found by Scan and the user's selection
+type detailSet struct {
+	found   []FileDetail
+	toClean []string
+}
+
+func (d *detailSet) Details() []FileDetail {
+	return d.found
+}
+
+func (d *detailSet) SetFilesToClean(paths []string) {
+	d.toClean = paths
+}
+
+// targets returns the entries Clean should act on: the explicit selection if
+// one was made, otherwise every entry marked Selected (CLI mode)
+func (d *detailSet) targets() []FileDetail {
+	var out []FileDetail
+	if d.toClean != nil {
+		wanted := make(map[string]bool, len(d.toClean))
+		for _, p := range d.toClean {
+			wanted[p] = true
+		}
+		for _, f := range d.found {
+			if wanted[f.Path] {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+	for _, f := range d.found {
+		if f.Selected {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// totalSize sums the sizes of the given entries
+func totalSize(details []FileDetail) int64 {
+	var size int64
+	for _, d := range details {
+		size += d.Size
+	}
+	return size
+}
+
+// formatSize formats bytes for entry labels, the same way the UI prints
+// sizes ("1.5 GB")
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// accessTime returns the last access time of a file, falling back to the
+// modification time when the platform data is unavailable
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Sec, st.Atim.Nsec)
+	}
+	return info.ModTime()
+}
+
+// parseByteSize parses human readable sizes such as "5.0G", "5.0 GiB",
+// "500M" or "1.2GB". Binary units are assumed for all suffixes.
+func parseByteSize(s string) int64 {
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	var mult float64 = 1
+	if s != "" {
+		if i := strings.IndexByte("KMGTP", s[len(s)-1]); i >= 0 {
+			mult = math.Pow(1024, float64(i+1))
+			s = s[:len(s)-1]
+		}
+	}
+
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(val * mult)
+}
+
+// parseDecimalSize parses sizes as ccache and docker print them, which are
+// decimal ("5.0G" and "1.2GB" are 5*10^9 and 1.2*10^9 bytes) unless written
+// with a binary suffix ("5Gi")
+func parseDecimalSize(s string) int64 {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "i") || strings.HasSuffix(s, "iB") {
+		return parseByteSize(s)
+	}
+	s = strings.TrimSuffix(strings.ToUpper(s), "B")
+
+	mult := 1.0
+	if s != "" {
+		if i := strings.IndexByte("KMGT", s[len(s)-1]); i >= 0 {
+			mult = math.Pow(1000, float64(i+1))
+			s = s[:len(s)-1]
+		}
+	}
+	val, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(val * mult)
+}
+
+// forceRemoveAll removes path like os.RemoveAll, but first makes every
+// directory below it writable. Build tools such as Bazel leave read-only
+// trees behind that os.RemoveAll alone cannot delete. Directories that could
+// not be read are opened up and walked again, as are the ones below them.
+func forceRemoveAll(path string) error {
+	for {
+		opened := false
+		filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if info == nil || !info.IsDir() {
+				return nil
+			}
+			if info.Mode().Perm()&0o700 != 0o700 {
+				if os.Chmod(p, info.Mode().Perm()|0o700) == nil && err != nil {
+					opened = true
+				}
+			}
+			return nil
+		})
+		if !opened {
+			break
+		}
+	}
+	return os.RemoveAll(path)
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// xdgDefaultUserDirs are the xdg-user-dirs defaults relative to home
+var xdgDefaultUserDirs = map[string]string{
+	"DESKTOP":     "Desktop",
+	"DOCUMENTS":   "Documents",
+	"DOWNLOAD":    "Downloads",
+	"MUSIC":       "Music",
+	"PICTURES":    "Pictures",
+	"PUBLICSHARE": "Public",
+	"TEMPLATES":   "Templates",
+	"VIDEOS":      "Videos",
+}
+
+// xdgUserDirs returns the XDG user dirs keyed by name ("DOWNLOAD",
+// "DOCUMENTS"...) as configured in ~/.config/user-dirs.dirs, or the defaults
+// when it is missing
+func xdgUserDirs(home string) map[string]string {
+	dirs := make(map[string]string)
+	f, err := os.Open(filepath.Join(home, ".config", "user-dirs.dirs"))
+	if err != nil {
+		for key, d := range xdgDefaultUserDirs {
+			dirs[key] = filepath.Join(home, d)
+		}
+		return dirs
+	}
+	defer f.Close()
+
+	// Lines look like XDG_DOWNLOAD_DIR="$HOME/Downloads"
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if strings.HasPrefix(line, "#") || !ok {
+			continue
+		}
+		key = strings.TrimSuffix(strings.TrimPrefix(key, "XDG_"), "_DIR")
+		value = strings.Trim(value, `"`)
+		value = strings.Replace(value, "$HOME", home, 1)
+		dirs[key] = filepath.Clean(value)
+	}
+	return dirs
+}
+
+// desktopEntryKeys returns the requested keys of the [Desktop Entry] group
+func desktopEntryKeys(path string, keys ...string) map[string]string {
+	out := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+
+	inEntry := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inEntry || !ok {
+			continue
+		}
+		for _, k := range keys {
+			if strings.TrimSpace(key) == k {
+				out[k] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return out
+}
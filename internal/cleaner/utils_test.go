@@ -0,0 +1,55 @@
+package cleaner
+
+import (
+	"testing"
+)
+
+func TestParseDecimalSize(t *testing.T) {
+	tests := map[string]int64{
+		"5.0G\n": 5 * 1000 * 1000 * 1000,
+		"500M":   500 * 1000 * 1000,
+		"2Gi":    2 * 1024 * 1024 * 1024,
+		"0":      0,
+		"12.3kB": 12300,
+		"1.2GB":  1200 * 1000 * 1000,
+	}
+	for in, want := range tests {
+		if got := parseDecimalSize(in); got != want {
+			t.Errorf("parseDecimalSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0-beta.1", "1.0.0", -1},
+		{"2023.1", "2023.3", -1},
+		{"1.0.0+build", "1.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"5.0G":    5 * 1024 * 1024 * 1024,
+		"5.0 GiB": 5 * 1024 * 1024 * 1024,
+		"500M":    500 * 1024 * 1024,
+		"1.5 KB":  1536,
+		"42":      42,
+		"bogus":   0,
+	}
+	for in, want := range tests {
+		if got := parseByteSize(in); got != want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cleaner
+
+import (
+	"encoding/binary"
+	"errors"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// vmDiskExts are the disk image formats checked for references
+var vmDiskExts = map[string]bool{".qcow2": true, ".vdi": true, ".vmdk": true}
+
+var (
+	// libvirtSourceRe matches <source file='...'/> in domain XML
+	libvirtSourceRe = regexp.MustCompile(`<source\s[^>]*file=['"]([^'"]+)['"]`)
+	// vboxLocationRe matches the location of <HardDisk> and <MachineEntry src=...>
+	vboxLocationRe = regexp.MustCompile(`(?:location|src)="([^"]+)"`)
+	// vagrantVersionRe matches box version dirs; "0" is an unversioned box
+	vagrantVersionRe = regexp.MustCompile(`^\d+(?:\.\d+)*$`)
+)
+
+// VMCleaner lists old Vagrant box versions, keeping the newest per box and
+// provider, and VM disk images (qcow2, vdi, vmdk) in the usual libvirt,
+// GNOME Boxes and VirtualBox dirs that no libvirt domain or VirtualBox
+// machine references. Unreferenced disks are flagged, not preselected.
+type VMCleaner struct {
+	detailSet
+
+	// boxes holds the Vagrant box entries, whose version dir goes with them
+	boxes map[string]bool
+}
+
+func (c *VMCleaner) Name() string {
+	return "Virtual Machines (Vagrant, libvirt, VirtualBox)"
+}
+
+func (c *VMCleaner) RequiresRoot() bool {
+	return false
+}
+
+func (c *VMCleaner) Scan() (int64, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	procs := SnapshotProcesses()
+	c.found = nil
+	c.boxes = make(map[string]bool)
+	c.scanVagrantBoxes(home, procs)
+	c.scanDisks(home, procs)
+	c.scanMultipass(home)
+	return totalSize(c.found), nil
+}
+
+// scanVagrantBoxes handles boxes/<name>/<version>/<provider>; "/" in box
+// names is stored as -VAGRANTSLASH-. Version dirs that are not versions
+// are left alone, so a stray dir can never count as the newest.
+func (c *VMCleaner) scanVagrantBoxes(home string, procs *ProcessSnapshot) {
+	vagrantHome := os.Getenv("VAGRANT_HOME")
+	if vagrantHome == "" {
+		vagrantHome = filepath.Join(home, ".vagrant.d")
+	}
+
+	boxDirs, _ := filepath.Glob(filepath.Join(vagrantHome, "boxes", "*"))
+	for _, boxDir := range boxDirs {
+		name := strings.ReplaceAll(filepath.Base(boxDir), "-VAGRANTSLASH-", "/")
+
+		// Versions are kept per provider: the newest virtualbox box says
+		// nothing about the libvirt one
+		byProvider := make(map[string][]string)
+		providers, _ := filepath.Glob(filepath.Join(boxDir, "*", "*"))
+		for _, p := range providers {
+			if dirExists(p) && vagrantVersionRe.MatchString(filepath.Base(filepath.Dir(p))) {
+				byProvider[filepath.Base(p)] = append(byProvider[filepath.Base(p)], filepath.Base(filepath.Dir(p)))
+			}
+		}
+
+		for _, provider := range sortedKeys(byProvider) {
+			versions := byProvider[provider]
+			sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) > 0 })
+			for _, v := range versions[1:] {
+				path := filepath.Join(boxDir, v, provider)
+				size, _ := simpleDirScan(path)
+				detail := FileDetail{
+					Path:     path,
+					Size:     size,
+					Label:    "Vagrant box " + name + " " + v + " (" + provider + ", newest is " + versions[0] + ")",
+					Selected: true,
+				}
+				if procs.InUse(path) {
+					detail.Warning = "in use"
+					detail.Selected = false
+				}
+				c.boxes[path] = true
+				c.found = append(c.found, detail)
+			}
+		}
+	}
+}
+
+// scanDisks flags disk images nothing references. System wide libvirt
+// images are only considered when every system domain XML is readable, and
+// need root to remove.
+func (c *VMCleaner) scanDisks(home string, procs *ProcessSnapshot) {
+	refs := make(map[string]bool)
+	files, _ := filepath.Glob(filepath.Join(home, ".config", "libvirt", "qemu", "*.xml"))
+	for _, f := range files {
+		addVMRefs(refs, f, libvirtSourceRe)
+	}
+	systemRefs := libvirtDomainRefs(refs, "/etc/libvirt/qemu")
+	vboxFiles, _ := filepath.Glob(filepath.Join(home, "VirtualBox VMs", "*", "*.vbox"))
+	vboxFiles = append(vboxFiles, filepath.Join(home, ".config", "VirtualBox", "VirtualBox.xml"))
+	for _, f := range vboxFiles {
+		addVMRefs(refs, f, vboxLocationRe)
+	}
+	// Registered machines may live outside ~/VirtualBox VMs
+	for ref := range refs {
+		if strings.HasSuffix(ref, ".vbox") {
+			addVMRefs(refs, ref, vboxLocationRe)
+		}
+	}
+
+	diskDirs := []string{
+		filepath.Join(home, ".local", "share", "libvirt", "images"),
+		filepath.Join(home, ".local", "share", "gnome-boxes", "images"),
+		filepath.Join(home, "VirtualBox VMs"),
+	}
+	if systemRefs {
+		diskDirs = append(diskDirs, "/var/lib/libvirt/images")
+	}
+
+	var disks []string
+	for _, dir := range diskDirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() && vmDiskExts[strings.ToLower(filepath.Ext(path))] {
+				disks = append(disks, path)
+			}
+			return nil
+		})
+	}
+
+	// Base images of qcow2 overlays are referenced through the overlay
+	for _, ref := range sortedKeys(refs) {
+		for backing := qcow2BackingFile(ref); backing != "" && !refs[backing]; backing = qcow2BackingFile(backing) {
+			refs[backing] = true
+		}
+	}
+
+	for _, d := range disks {
+		if refs[d] {
+			continue
+		}
+		info, err := os.Stat(d)
+		if err != nil {
+			continue
+		}
+		detail := FileDetail{
+			Path:  d,
+			Size:  info.Size(),
+			Label: d + " (not referenced by any VM)",
+		}
+		if procs.InUse(d) {
+			detail.Warning = "in use"
+		} else if !removable(d) {
+			detail.Warning = "needs root"
+		}
+		c.found = append(c.found, detail)
+	}
+}
+
+// libvirtDomainRefs adds the disks the domain XML files in dir refer to and
+// reports whether all of them could be read. A domain we cannot read may use
+// any image, so then none of them count as unreferenced.
+func libvirtDomainRefs(refs map[string]bool, dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	found := false
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".xml" {
+			continue
+		}
+		if !addVMRefs(refs, filepath.Join(dir, e.Name()), libvirtSourceRe) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// addVMRefs adds the disk paths a VM definition refers to and reports
+// whether it could be read. VirtualBox stores them relative to the machine
+// dir.
+func addVMRefs(refs map[string]bool, file string, re *regexp.Regexp) bool {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+		p := html.UnescapeString(m[1])
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(file), p)
+		}
+		refs[filepath.Clean(p)] = true
+	}
+	return true
+}
+
+// qcow2BackingFile returns the backing file recorded in a qcow2 header, if any
+func qcow2BackingFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	// magic, version, backing_file_offset (u64), backing_file_size (u32)
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:4]) != "QFI\xfb" {
+		return ""
+	}
+	offset := binary.BigEndian.Uint64(header[8:16])
+	size := binary.BigEndian.Uint32(header[16:20])
+	if offset == 0 || size == 0 || size > 4096 {
+		return ""
+	}
+	name := make([]byte, size)
+	if _, err := f.ReadAt(name, int64(offset)); err != nil {
+		return ""
+	}
+	backing := string(name)
+	if !filepath.IsAbs(backing) {
+		backing = filepath.Join(filepath.Dir(path), backing)
+	}
+	return filepath.Clean(backing)
+}
+
+// scanMultipass lists the cached Ubuntu images multipass downloads for new
+// instances. The instances' own disks are managed by multipassd.
+func (c *VMCleaner) scanMultipass(home string) {
+	for _, dir := range []string{
+		filepath.Join(home, ".local", "share", "multipass", "vault", "images"),
+		"/var/snap/multipass/common/cache/multipassd/vault/images",
+	} {
+		images, _ := os.ReadDir(dir)
+		for _, img := range images {
+			path := filepath.Join(dir, img.Name())
+			size, _ := simpleDirScan(path)
+			if size == 0 {
+				continue
+			}
+			c.found = append(c.found, FileDetail{
+				Path:  path,
+				Size:  size,
+				Label: "multipass image cache " + img.Name(),
+			})
+		}
+	}
+}
+
+func (c *VMCleaner) Clean() error {
+	var errs []error
+	for _, t := range c.targets() {
+		if err := os.RemoveAll(t.Path); err != nil {
+			errs = append(errs, err)
+		} else if c.boxes[t.Path] {
+			// Drop the Vagrant version dir once its last provider is gone
+			_ = os.Remove(filepath.Dir(t.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package cleaner
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVMDiskReferences(t *testing.T) {
+	dir := t.TempDir()
+
+	// A qcow2 overlay pointing at its base image by relative name
+	backing := "base.qcow2"
+	header := make([]byte, 512)
+	copy(header, "QFI\xfb")
+	binary.BigEndian.PutUint32(header[4:], 3)
+	binary.BigEndian.PutUint64(header[8:], 256)
+	binary.BigEndian.PutUint32(header[16:], uint32(len(backing)))
+	copy(header[256:], backing)
+	overlay := filepath.Join(dir, "vm.qcow2")
+	if err := os.WriteFile(overlay, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := qcow2BackingFile(overlay); got != filepath.Join(dir, backing) {
+		t.Errorf("qcow2BackingFile() = %q", got)
+	}
+
+	vbox := filepath.Join(dir, "vm.vbox")
+	xml := `<HardDisk uuid="{1}" location="disks/My &amp; Disk.vdi" format="VDI"/>`
+	if err := os.WriteFile(vbox, []byte(xml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	refs := make(map[string]bool)
+	addVMRefs(refs, vbox, vboxLocationRe)
+	if !refs[filepath.Join(dir, "disks", "My & Disk.vdi")] {
+		t.Errorf("addVMRefs() = %v", refs)
+	}
+
+	// System images only count as unreferenced when every domain was read
+	qemu := filepath.Join(dir, "qemu")
+	if libvirtDomainRefs(refs, qemu) {
+		t.Error("libvirtDomainRefs() = true without a domain dir")
+	}
+	writeTestFiles(t, qemu, map[string]string{
+		"web.xml": `<disk type='file'><source file='/var/lib/libvirt/images/web.qcow2'/></disk>`,
+	})
+	if !libvirtDomainRefs(refs, qemu) || !refs["/var/lib/libvirt/images/web.qcow2"] {
+		t.Errorf("libvirtDomainRefs() missed web.xml: %v", refs)
+	}
+	if os.Geteuid() != 0 {
+		writeTestFiles(t, qemu, map[string]string{"db.xml": ""})
+		if err := os.Chmod(filepath.Join(qemu, "db.xml"), 0); err != nil {
+			t.Fatal(err)
+		}
+		if libvirtDomainRefs(refs, qemu) {
+			t.Error("libvirtDomainRefs() = true with an unreadable domain")
+		}
+	}
+}
+
+func TestVMVagrantBoxes(t *testing.T) {
+	box := ".vagrant.d/boxes/generic-VAGRANTSLASH-debian12/"
+	runScanCases(t, func() DetailCleaner { return &VMCleaner{} }, []scanCase{
+		{
+			name: "older versions per provider",
+			files: map[string]string{
+				box + "4.3.2/libvirt/box.img":      "old",
+				box + "4.3.12/libvirt/box.img":     "new",
+				box + "4.3.2/virtualbox/box.vmdk":  "only",
+				box + "4.3.12/virtualbox/metadata": "{}",
+				box + "0/libvirt/box.img":          "unversioned",
+			},
+			want: map[string]bool{
+				box + "4.3.2/libvirt":    true,
+				box + "0/libvirt":        true,
+				box + "4.3.2/virtualbox": true,
+			},
+			labels: map[string]string{
+				box + "4.3.2/libvirt": "Vagrant box generic/debian12 4.3.2 (libvirt, newest is 4.3.12)",
+			},
+		},
+		{
+			name: "stray dir is not a version",
+			files: map[string]string{
+				box + "4.3.2/libvirt/box.img":    "old",
+				box + "4.3.12/libvirt/box.img":   "new",
+				box + "backup/libvirt/box.img":   "copy",
+				box + "zz-test/libvirt/box.img":  "copy",
+				box + "4.3.12.bak/libvirt/x.img": "copy",
+			},
+			want: map[string]bool{
+				box + "4.3.2/libvirt": true,
+			},
+			labels: map[string]string{
+				box + "4.3.2/libvirt": "Vagrant box generic/debian12 4.3.2 (libvirt, newest is 4.3.12)",
+			},
+		},
+	})
+}
@@ -46,7 +46,8 @@ type state int
 const (
 	stateScanning state = iota
 	stateReview
-	stateLargeFileSelection
+	stateDetailSelection
+	stateDuplicateSelection
 	stateConfirm // New Confirmation State
 	stateCleaning
 	stateDone
@@ -61,12 +62,103 @@ type item struct {
 	err            error
 	skip           bool
 	statusOverride string
+
+	// Entries reported by a cleaner.DetailCleaner
+	details []*detailItem
+
+	// Groups reported by a cleaner.GroupCleaner
+	groups []*groupItem
+	mode   cleaner.DedupMode
 }
 
-type largeFileItem struct {
+type detailItem struct {
 	path     string
+	label    string
 	size     int64
 	selected bool
+	warning  string
+}
+
+// groupItem is one set of identical files; keep is the index of the copy
+// to keep, or -1 to leave the group alone
+type groupItem struct {
+	id    string
+	size  int64
+	files []string
+	keep  int
+}
+
+// groupRow is one line of the duplicate screen: a group header (file -1)
+// or one of its files
+type groupRow struct {
+	group int
+	file  int
+}
+
+// isDetail reports whether the item drills down into individual entries
+func (it *item) isDetail() bool {
+	_, ok := it.cleaner.(cleaner.DetailCleaner)
+	return ok
+}
+
+// selectedDetails returns the number and total size of selected entries
+func (it *item) selectedDetails() (int, int64) {
+	var count int
+	var size int64
+	for _, d := range it.details {
+		if d.selected {
+			count++
+			size += d.size
+		}
+	}
+	return count, size
+}
+
+// isGroup reports whether the item opens the duplicate screen
+func (it *item) isGroup() bool {
+	_, ok := it.cleaner.(cleaner.GroupCleaner)
+	return ok
+}
+
+// selectedGroups returns the number of groups acted on and the space freed
+func (it *item) selectedGroups() (int, int64) {
+	var count int
+	var size int64
+	for _, g := range it.groups {
+		if g.keep >= 0 {
+			count++
+			size += g.size * int64(len(g.files)-1)
+		}
+	}
+	return count, size
+}
+
+// groupRows flattens the groups into the lines of the duplicate screen
+func (it *item) groupRows() []groupRow {
+	var rows []groupRow
+	for gi, g := range it.groups {
+		rows = append(rows, groupRow{group: gi, file: -1})
+		for fi := range g.files {
+			rows = append(rows, groupRow{group: gi, file: fi})
+		}
+	}
+	return rows
+}
+
+// selectedSize is what cleaning this item would free with the current selection
+func (it *item) selectedSize() int64 {
+	if !it.selected {
+		return 0
+	}
+	if it.isDetail() {
+		_, size := it.selectedDetails()
+		return size
+	}
+	if it.isGroup() {
+		_, size := it.selectedGroups()
+		return size
+	}
+	return it.size
 }
 
 type model struct {
@@ -74,12 +166,9 @@ type model struct {
 	items  []*item
 	cursor int
 
-	// Large File Sub-menu
-	largeFiles      []*largeFileItem
-	lfCursor        int
-	lfCleanerIndex  int
-	lfSelectedCount int
-	lfSelectedSize  int64
+	// Detail Sub-menu, also used by the duplicate screen
+	detailIndex  int
+	detailCursor int
 
 	spinner   spinner.Model
 	totalSize int64
@@ -97,7 +186,6 @@ func InitialModel(cleaners []cleaner.Cleaner) model {
 	isRoot := os.Geteuid() == 0
 
 	items := make([]*item, len(cleaners))
-	lfIndex := -1
 
 	for i, c := range cleaners {
 		skip := c.RequiresRoot() && !isRoot
@@ -107,18 +195,20 @@ func InitialModel(cleaners []cleaner.Cleaner) model {
 			skip:     skip,
 		}
 
-		if _, ok := c.(*cleaner.LargeFileCleaner); ok {
-			lfIndex = i
+		// Selection follows the entries once the scan reports them
+		if items[i].isDetail() || items[i].isGroup() {
 			items[i].selected = false
 		}
+		if gc, ok := c.(cleaner.GroupCleaner); ok {
+			items[i].mode = gc.Mode()
+		}
 	}
 
 	return model{
-		state:          stateScanning,
-		items:          items,
-		lfCleanerIndex: lfIndex,
-		spinner:        s,
-		isRoot:         isRoot,
+		state:   stateScanning,
+		items:   items,
+		spinner: s,
+		isRoot:  isRoot,
 	}
 }
 
@@ -135,7 +225,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		// Global quit (unless in submenu or confirm)
 		case "q":
-			if m.state == stateLargeFileSelection {
+			if m.state == stateDetailSelection || m.state == stateDuplicateSelection {
 				m.state = stateReview
 				return m, nil
 			}
@@ -162,45 +252,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Handle Space on Clean Button
 				if m.cursor == len(m.items) {
 					// Trigger Clean Logic
-					hasSelection := false
-					for _, it := range m.items {
-						if it.selected {
-							hasSelection = true
-							break
-						}
-					}
-					if hasSelection {
-						m.state = stateConfirm
-						if m.lfCleanerIndex >= 0 {
-							var selectedPaths []string
-							for _, lf := range m.largeFiles {
-								if lf.selected {
-									selectedPaths = append(selectedPaths, lf.path)
-								}
-							}
-							if lfc, ok := m.items[m.lfCleanerIndex].cleaner.(*cleaner.LargeFileCleaner); ok {
-								lfc.SetFilesToClean(selectedPaths)
-							}
-						}
-						return m, nil
-					}
+					m.confirmClean()
 					return m, nil
 				}
 
-				// Handle Space on Large File Cleaner -> Drill Down
-				if m.lfCleanerIndex >= 0 && m.cursor == m.lfCleanerIndex && !m.items[m.cursor].skip {
-					m.state = stateLargeFileSelection
-					if len(m.largeFiles) == 0 {
-						if lfc, ok := m.items[m.lfCleanerIndex].cleaner.(*cleaner.LargeFileCleaner); ok {
-							for _, f := range lfc.FoundFiles {
-								m.largeFiles = append(m.largeFiles, &largeFileItem{
-									path:     f.Path,
-									size:     f.Size,
-									selected: false,
-								})
-							}
-						}
-					}
+				// Handle Space on Detail Cleaners -> Drill Down
+				if m.cursor < len(m.items) && m.items[m.cursor].isDetail() && !m.items[m.cursor].skip {
+					m.state = stateDetailSelection
+					m.detailIndex = m.cursor
+					m.detailCursor = 0
+					return m, nil
+				}
+				if m.cursor < len(m.items) && m.items[m.cursor].isGroup() && !m.items[m.cursor].skip {
+					m.state = stateDuplicateSelection
+					m.detailIndex = m.cursor
+					m.detailCursor = 0
 					return m, nil
 				}
 
@@ -216,45 +282,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Handle Enter on Clean Button
 				if m.cursor == len(m.items) {
 					// Trigger Clean Logic
-					hasSelection := false
-					for _, it := range m.items {
-						if it.selected {
-							hasSelection = true
-							break
-						}
-					}
-					if hasSelection {
-						m.state = stateConfirm
-						if m.lfCleanerIndex >= 0 {
-							var selectedPaths []string
-							for _, lf := range m.largeFiles {
-								if lf.selected {
-									selectedPaths = append(selectedPaths, lf.path)
-								}
-							}
-							if lfc, ok := m.items[m.lfCleanerIndex].cleaner.(*cleaner.LargeFileCleaner); ok {
-								lfc.SetFilesToClean(selectedPaths)
-							}
-						}
-						return m, nil
-					}
+					m.confirmClean()
 					return m, nil
 				}
 
-				// Drill down for Large Files
-				if m.cursor == m.lfCleanerIndex && !m.items[m.cursor].skip {
-					m.state = stateLargeFileSelection
-					if len(m.largeFiles) == 0 {
-						if lfc, ok := m.items[m.cursor].cleaner.(*cleaner.LargeFileCleaner); ok {
-							for _, f := range lfc.FoundFiles {
-								m.largeFiles = append(m.largeFiles, &largeFileItem{
-									path:     f.Path,
-									size:     f.Size,
-									selected: false,
-								})
-							}
-						}
-					}
+				// Drill down for Detail Cleaners
+				if m.items[m.cursor].isDetail() && !m.items[m.cursor].skip {
+					m.state = stateDetailSelection
+					m.detailIndex = m.cursor
+					m.detailCursor = 0
+					return m, nil
+				}
+				if m.items[m.cursor].isGroup() && !m.items[m.cursor].skip {
+					m.state = stateDuplicateSelection
+					m.detailIndex = m.cursor
+					m.detailCursor = 0
 					return m, nil
 				}
 
@@ -269,28 +311,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			case "c": // Hotkey Trigger
 				// Trigger Clean Logic
-				hasSelection := false
-				for _, it := range m.items {
-					if it.selected {
-						hasSelection = true
-						break
-					}
-				}
-				if hasSelection {
-					m.state = stateConfirm
-					if m.lfCleanerIndex >= 0 {
-						var selectedPaths []string
-						for _, lf := range m.largeFiles {
-							if lf.selected {
-								selectedPaths = append(selectedPaths, lf.path)
-							}
-						}
-						if lfc, ok := m.items[m.lfCleanerIndex].cleaner.(*cleaner.LargeFileCleaner); ok {
-							lfc.SetFilesToClean(selectedPaths)
-						}
-					}
-					return m, nil
-				}
+				m.confirmClean()
+				return m, nil
 			}
 
 		} else if m.state == stateConfirm {
@@ -303,34 +325,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = stateReview
 			}
 
-		} else if m.state == stateLargeFileSelection {
+		} else if m.state == stateDetailSelection {
+			it := m.items[m.detailIndex]
 			switch msg.String() {
 			case "esc", "backspace", "left", "h":
 				m.state = stateReview
 			case "up", "k":
-				if m.lfCursor > 0 {
-					m.lfCursor--
+				if m.detailCursor > 0 {
+					m.detailCursor--
 				}
 			case "down", "j":
-				if m.lfCursor < len(m.largeFiles)-1 {
-					m.lfCursor++
+				if m.detailCursor < len(it.details)-1 {
+					m.detailCursor++
 				}
 			case " ", "enter":
-				if len(m.largeFiles) > 0 {
-					m.largeFiles[m.lfCursor].selected = !m.largeFiles[m.lfCursor].selected
-
-					// Re-calc summary
-					m.lfSelectedCount = 0
-					m.lfSelectedSize = 0
-					for _, lf := range m.largeFiles {
-						if lf.selected {
-							m.lfSelectedCount++
-							m.lfSelectedSize += lf.size
-						}
-					}
+				if len(it.details) > 0 {
+					it.details[m.detailCursor].selected = !it.details[m.detailCursor].selected
+					count, _ := it.selectedDetails()
+					it.selected = count > 0
+				}
+			case "a":
+				// Toggle all: select everything unless everything is already selected
+				count, _ := it.selectedDetails()
+				all := count < len(it.details)
+				for _, d := range it.details {
+					d.selected = all
+				}
+				it.selected = all && len(it.details) > 0
+			}
 
-					m.items[m.lfCleanerIndex].selected = m.lfSelectedCount > 0
+		} else if m.state == stateDuplicateSelection {
+			it := m.items[m.detailIndex]
+			rows := it.groupRows()
+			switch msg.String() {
+			case "esc", "backspace", "left", "h":
+				m.state = stateReview
+			case "up", "k":
+				if m.detailCursor > 0 {
+					m.detailCursor--
+				}
+			case "down", "j":
+				if m.detailCursor < len(rows)-1 {
+					m.detailCursor++
 				}
+			case " ", "enter":
+				if len(rows) > 0 {
+					row := rows[m.detailCursor]
+					g := it.groups[row.group]
+					switch {
+					case row.file >= 0:
+						// Picking a copy also includes the group
+						g.keep = row.file
+					case g.keep >= 0:
+						g.keep = -1
+					default:
+						g.keep = 0
+					}
+					count, _ := it.selectedGroups()
+					it.selected = count > 0
+				}
+			case "a":
+				// Toggle all groups, keeping the oldest copy of each
+				count, _ := it.selectedGroups()
+				all := count < len(it.groups)
+				for _, g := range it.groups {
+					if !all {
+						g.keep = -1
+					} else if g.keep < 0 {
+						g.keep = 0
+					}
+				}
+				it.selected = all && len(it.groups) > 0
+			case "m":
+				it.mode = (it.mode + 1) % (cleaner.DedupReflink + 1)
 			}
 		}
 
@@ -339,6 +406,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 	case spinner.TickMsg:
+		// Long running cleaners report progress; the spinner tick refreshes it
+		if m.state == stateCleaning {
+			for _, it := range m.items {
+				if pc, ok := it.cleaner.(cleaner.ProgressCleaner); ok && it.selected && !it.cleaned {
+					it.statusOverride = pc.Progress()
+				}
+			}
+		}
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
@@ -349,6 +424,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				it.size = msg.size
 				it.err = msg.err
 				it.scanned = true
+
+				if dc, ok := it.cleaner.(cleaner.DetailCleaner); ok && msg.err == nil && !it.skip {
+					it.details = nil
+					for _, f := range dc.Details() {
+						label := f.Label
+						if label == "" {
+							label = f.Path
+						}
+						it.details = append(it.details, &detailItem{
+							path:     f.Path,
+							label:    label,
+							size:     f.Size,
+							selected: f.Selected,
+							warning:  f.Warning,
+						})
+					}
+					count, _ := it.selectedDetails()
+					it.selected = count > 0
+				}
+
+				// Groups start unselected: which copy to keep is the user's call
+				if gc, ok := it.cleaner.(cleaner.GroupCleaner); ok && msg.err == nil && !it.skip {
+					it.groups = nil
+					for _, g := range gc.Groups() {
+						it.groups = append(it.groups, &groupItem{
+							id:    g.ID,
+							size:  g.Size,
+							files: g.Files,
+							keep:  -1,
+						})
+					}
+					it.selected = false
+				}
 			}
 		}
 
@@ -487,13 +595,20 @@ func (m model) View() string {
 			sizeStr := formatBytes(it.size)
 
 			extras := ""
-			if i == m.lfCleanerIndex {
-				if m.lfSelectedCount > 0 {
-					sizeStr = fmt.Sprintf("%s / %s", formatBytes(m.lfSelectedSize), formatBytes(it.size))
-					extras = greenStyle.Render(fmt.Sprintf(" (%d files selected)", m.lfSelectedCount))
+			if it.isDetail() {
+				if count, size := it.selectedDetails(); count > 0 {
+					sizeStr = fmt.Sprintf("%s / %s", formatBytes(size), formatBytes(it.size))
+					extras = greenStyle.Render(fmt.Sprintf(" (%d selected)", count))
 				} else if it.size > 0 && !it.skip {
 					extras = subtleStyle.Render(" (Enter/Space to detail)")
 				}
+			} else if it.isGroup() {
+				if count, size := it.selectedGroups(); count > 0 {
+					sizeStr = fmt.Sprintf("%s / %s", formatBytes(size), formatBytes(it.size))
+					extras = greenStyle.Render(fmt.Sprintf(" (%d groups, %s)", count, it.mode))
+				} else if it.size > 0 && !it.skip {
+					extras = subtleStyle.Render(" (Enter/Space to pick copies)")
+				}
 			}
 
 			if it.skip {
@@ -526,12 +641,8 @@ func (m model) View() string {
 
 		// Re-calculate Total Selected
 		var totalSelectedSize int64
-		for i, it := range m.items {
-			if i == m.lfCleanerIndex {
-				totalSelectedSize += m.lfSelectedSize
-			} else if it.selected {
-				totalSelectedSize += it.size
-			}
+		for _, it := range m.items {
+			totalSelectedSize += it.selectedSize()
 		}
 
 		s.WriteString("\n " + greenStyle.Render(fmt.Sprintf("Total Selected to Clean: %s", formatBytes(totalSelectedSize))) + "\n")
@@ -561,14 +672,9 @@ func (m model) View() string {
 
 		var totalSelectedSize int64
 		itemCount := 0
-		for i, it := range m.items {
-			if i == m.lfCleanerIndex {
-				if m.lfSelectedCount > 0 {
-					totalSelectedSize += m.lfSelectedSize
-					itemCount++
-				}
-			} else if it.selected {
-				totalSelectedSize += it.size
+		for _, it := range m.items {
+			if it.selected {
+				totalSelectedSize += it.selectedSize()
 				itemCount++
 			}
 		}
@@ -593,39 +699,43 @@ func (m model) View() string {
 		s.WriteString(strings.Repeat("\n", topPad))
 		s.WriteString(lipgloss.PlaceHorizontal(m.width-6, lipgloss.Center, modal))
 
-	case stateLargeFileSelection:
-		s.WriteString(" Select Large Files to Delete:\n\n")
+	case stateDetailSelection:
+		it := m.items[m.detailIndex]
+		s.WriteString(fmt.Sprintf(" Select entries to clean: %s\n\n", it.cleaner.Name()))
 
-		if len(m.largeFiles) == 0 {
-			s.WriteString(subtleStyle.Render("  No large unused files found.\n"))
+		if len(it.details) == 0 {
+			s.WriteString(subtleStyle.Render("  Nothing found.\n"))
 		} else {
-			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(fmt.Sprintf("   %-3s %-50s %10s", "   ", "Path", "Size")) + "\n")
+			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(fmt.Sprintf("   %-3s %-50s %10s", "   ", "Entry", "Size")) + "\n")
 
-			start, end := m.getPaginatorBounds(m.height - 10)
+			start, end := getPaginatorBounds(m.height-10, len(it.details), m.detailCursor)
 
 			for i := start; i < end; i++ {
-				lf := m.largeFiles[i]
+				d := it.details[i]
 
 				checked := "[ ]"
-				if lf.selected {
+				if d.selected {
 					checked = "[x]"
 				}
 
 				cursor := "   "
-				if m.lfCursor == i {
+				if m.detailCursor == i {
 					cursor = " > "
 				}
 
 				style := lipgloss.NewStyle()
-				if m.lfCursor == i {
+				if m.detailCursor == i {
 					style = selectedItemStyle
 					checked = style.Render(checked)
-				} else if !lf.selected {
+				} else if !d.selected {
 					style = style.Foreground(lipgloss.Color("241"))
 				}
 
-				path := lf.path
+				path := d.label
 				availWidth := m.width - 25
+				if d.warning != "" {
+					availWidth -= len(d.warning) + 3
+				}
 				if availWidth < 20 {
 					availWidth = 20
 				}
@@ -634,16 +744,73 @@ func (m model) View() string {
 					path = "..." + path[len(path)-(availWidth-3):]
 				}
 
-				line := fmt.Sprintf("%s %s %-*s %10s", cursor, checked, availWidth, path, formatBytes(lf.size))
-				if m.lfCursor == i {
-					line = fmt.Sprintf("%s %s %-*s %10s", cursor, checked, availWidth, style.Render(path), style.Render(formatBytes(lf.size)))
+				line := fmt.Sprintf("%s %s %-*s %10s", cursor, checked, availWidth, path, formatBytes(d.size))
+				if m.detailCursor == i {
+					line = fmt.Sprintf("%s %s %-*s %10s", cursor, checked, availWidth, style.Render(path), style.Render(formatBytes(d.size)))
+				}
+				if d.warning != "" {
+					line += " " + orangeStyle.Render("⚠ "+d.warning)
 				}
 
 				s.WriteString(line + "\n")
 			}
 		}
 
-		s.WriteString("\n" + subtleStyle.Render(" ↑/↓: Navigate • Space/Enter: Toggle • Esc/Back: Save & Return"))
+		s.WriteString("\n" + subtleStyle.Render(" ↑/↓: Navigate • Space/Enter: Toggle • a: Toggle All • Esc/Back: Save & Return"))
+
+	case stateDuplicateSelection:
+		it := m.items[m.detailIndex]
+		s.WriteString(fmt.Sprintf(" Pick the copy to keep: %s\n", it.cleaner.Name()))
+		s.WriteString(fmt.Sprintf(" Other copies: %s\n\n", orangeStyle.Render(dedupAction(it.mode))))
+
+		rows := it.groupRows()
+		if len(rows) == 0 {
+			s.WriteString(subtleStyle.Render("  No duplicates found.\n"))
+		}
+
+		start, end := getPaginatorBounds(m.height-11, len(rows), m.detailCursor)
+		availWidth := m.width - 25
+		if availWidth < 20 {
+			availWidth = 20
+		}
+
+		for i := start; i < end; i++ {
+			row := rows[i]
+			g := it.groups[row.group]
+
+			cursor := "   "
+			style := lipgloss.NewStyle()
+			if m.detailCursor == i {
+				cursor = " > "
+				style = selectedItemStyle
+			} else if g.keep < 0 {
+				style = style.Foreground(lipgloss.Color("241"))
+			}
+
+			if row.file < 0 {
+				checked := "[ ]"
+				if g.keep >= 0 {
+					checked = "[x]"
+				}
+				header := fmt.Sprintf("%d copies of %s, frees %s", len(g.files), formatBytes(g.size), formatBytes(g.size*int64(len(g.files)-1)))
+				s.WriteString(fmt.Sprintf("%s %s %s\n", cursor, style.Render(checked), style.Render(header)))
+				continue
+			}
+
+			path := g.files[row.file]
+			if len(path) > availWidth {
+				path = "..." + path[len(path)-(availWidth-3):]
+			}
+			tag := "     "
+			if g.keep == row.file {
+				tag = greenStyle.Render("keep ")
+			} else if g.keep >= 0 {
+				tag = orangeStyle.Render(fmt.Sprintf("%-5s", it.mode))
+			}
+			s.WriteString(fmt.Sprintf("%s     %s %s\n", cursor, tag, style.Render(path)))
+		}
+
+		s.WriteString("\n" + subtleStyle.Render(" ↑/↓: Navigate • Space/Enter: Keep this copy / toggle group • a: Toggle All • m: Mode • Esc/Back: Save & Return"))
 
 	case stateCleaning:
 		s.WriteString(fmt.Sprintf(" %s Cleaning selected items...\n\n", m.spinner.View()))
@@ -653,6 +820,9 @@ func (m model) View() string {
 			}
 			icon := "•"
 			status := "Waiting..."
+			if it.statusOverride != "" {
+				status = subtleStyle.Render(it.statusOverride)
+			}
 			if it.cleaned {
 				if it.err != nil {
 					icon = crossMark.String()
@@ -673,20 +843,21 @@ func (m model) View() string {
 	return doc.Render(s.String())
 }
 
-func (m model) getPaginatorBounds(maxRows int) (int, int) {
+// getPaginatorBounds returns the window of rows to render around cursor
+func getPaginatorBounds(maxRows, count, cursor int) (int, int) {
 	if maxRows < 5 {
 		maxRows = 5
 	}
-	if len(m.largeFiles) <= maxRows {
-		return 0, len(m.largeFiles)
+	if count <= maxRows {
+		return 0, count
 	}
-	start := m.lfCursor - (maxRows / 2)
+	start := cursor - (maxRows / 2)
 	if start < 0 {
 		start = 0
 	}
 	end := start + maxRows
-	if end > len(m.largeFiles) {
-		end = len(m.largeFiles)
+	if end > count {
+		end = count
 		start = end - maxRows
 		if start < 0 {
 			start = 0
@@ -695,6 +866,48 @@ func (m model) getPaginatorBounds(maxRows int) (int, int) {
 	return start, end
 }
 
+// confirmClean moves to the confirmation modal if anything is selected and
+// hands each detail cleaner the entries the user picked
+func (m *model) confirmClean() {
+	hasSelection := false
+	for _, it := range m.items {
+		if it.selected {
+			hasSelection = true
+			break
+		}
+	}
+	if !hasSelection {
+		return
+	}
+
+	m.state = stateConfirm
+	for _, it := range m.items {
+		if gc, ok := it.cleaner.(cleaner.GroupCleaner); ok {
+			keep := make(map[string]string)
+			for _, g := range it.groups {
+				if g.keep >= 0 {
+					keep[g.id] = g.files[g.keep]
+				}
+			}
+			gc.SetKeep(keep)
+			gc.SetMode(it.mode)
+			continue
+		}
+
+		dc, ok := it.cleaner.(cleaner.DetailCleaner)
+		if !ok {
+			continue
+		}
+		selectedPaths := []string{}
+		for _, d := range it.details {
+			if d.selected {
+				selectedPaths = append(selectedPaths, d.path)
+			}
+		}
+		dc.SetFilesToClean(selectedPaths)
+	}
+}
+
 // Helpers
 type scanResultMsg struct {
 	cleaner cleaner.Cleaner
@@ -737,6 +950,17 @@ func cleanCmd(items []*item) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// dedupAction describes what happens to the copies that are not kept
+func dedupAction(mode cleaner.DedupMode) string {
+	switch mode {
+	case cleaner.DedupHardlink:
+		return "replaced by hard links (m to change)"
+	case cleaner.DedupReflink:
+		return "replaced by reflinks, needs Btrfs/XFS (m to change)"
+	}
+	return "deleted (m to change)"
+}
+
 func formatBytes(b int64) string {
 	const unit = 1024
 	if b < unit {